@@ -4,20 +4,28 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io/fs"
 	"log"
 	"net/http"
+	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
 	"github.com/avstrong/booking/internal/booking"
+	"github.com/avstrong/booking/internal/boost"
+	"github.com/avstrong/booking/internal/exchange/file"
 	"github.com/avstrong/booking/internal/idgen/simple"
 	"github.com/avstrong/booking/internal/logger"
 	"github.com/avstrong/booking/internal/migration"
+	"github.com/avstrong/booking/internal/money"
 	"github.com/avstrong/booking/internal/storage/memory"
 	"github.com/avstrong/booking/internal/transport/web"
 )
 
+// defaultExchangeRatesPath is used when EXCHANGE_RATES_PATH is not set. It is relative to the working directory.
+const defaultExchangeRatesPath = "data/exchange_rates.csv"
+
 func Run(l *logger.Logger) error {
 	ctx, cancel := signal.NotifyContext(
 		context.Background(),
@@ -36,8 +44,92 @@ func Run(l *logger.Logger) error {
 
 	l.LogInfo("Test migration has been applied")
 
+	exchangeRatesPath := os.Getenv("EXCHANGE_RATES_PATH")
+	if exchangeRatesPath == "" {
+		exchangeRatesPath = defaultExchangeRatesPath
+	}
+
+	// Without the file the service still books in the currencies of the rates, only display currencies are off.
+	exchangeRates, err := file.New(file.Config{L: l, Path: exchangeRatesPath})
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		l.LogErrorf("Exchange rates file %v is not found, display currencies are disabled", exchangeRatesPath)
+	case err != nil:
+		return fmt.Errorf("load exchange rates: %w", err)
+	}
+
+	boostConf := boost.Config{
+		Loyalty: boost.LoyaltyProgram{
+			Window: 365 * 24 * time.Hour, //nolint:gomnd
+			Tiers: []boost.LoyaltyTier{
+				{
+					Name:               "silver",
+					MinNights:          5,                              //nolint:gomnd
+					MinSpend:           money.FromMajor(50_000, "RUB"), //nolint:gomnd
+					DiscountPercentage: 5,                              //nolint:gomnd
+					Perks:              []string{"late_checkout"},
+				},
+				{
+					Name:               "gold",
+					MinNights:          15,                              //nolint:gomnd
+					MinSpend:           money.FromMajor(150_000, "RUB"), //nolint:gomnd
+					DiscountPercentage: 10,                              //nolint:gomnd
+					Perks:              []string{"late_checkout", "room_upgrade"},
+				},
+			},
+		},
+	}
+	boostManager := boost.New(boostConf, storage)
+
 	idGen := simple.New()
-	bookManager := booking.New(l, storage, idGen)
+	bookConf := booking.Config{
+		L:                 l,
+		HoldTTL:           15 * time.Minute,      //nolint:gomnd
+		CommitAttempts:    3,                     //nolint:gomnd
+		CommitRetryDelay:  10 * time.Millisecond, //nolint:gomnd
+		IdempotencyKeyTTL: 24 * time.Hour,        //nolint:gomnd
+	}
+
+	bookManager := booking.New(bookConf, storage, idGen, nil, boostManager)
+	if exchangeRates != nil {
+		bookManager = booking.New(bookConf, storage, idGen, exchangeRates, boostManager)
+	}
+
+	go runPeriodically(ctx, l, "hold reaper", 30*time.Second, func(ctx context.Context) error { //nolint:gomnd
+		released, err := bookManager.ReleaseExpiredHolds(ctx)
+		if err != nil {
+			return fmt.Errorf("release expired holds: %w", err)
+		}
+
+		if released > 0 {
+			l.LogInfo("Released %v expired holds", released)
+		}
+
+		return nil
+	})
+
+	go runPeriodically(ctx, l, "idempotency key sweeper", 10*time.Minute, func(ctx context.Context) error { //nolint:gomnd
+		purged, err := bookManager.PurgeExpiredIdempotencyKeys(ctx)
+		if err != nil {
+			return fmt.Errorf("purge expired idempotency keys: %w", err)
+		}
+
+		if purged > 0 {
+			l.LogInfo("Purged %v expired idempotency keys", purged)
+		}
+
+		return nil
+	})
+
+	if exchangeRates != nil {
+		go runPeriodically(ctx, l, "exchange rates reloader", time.Minute, func(_ context.Context) error {
+			if _, err := exchangeRates.Reload(); err != nil {
+				return fmt.Errorf("reload exchange rates: %w", err)
+			}
+
+			return nil
+		})
+	}
 
 	webConf := web.Conf{
 		L:                 l,
@@ -48,7 +140,7 @@ func Run(l *logger.Logger) error {
 		LivenessEndpoint:  "/liveness",
 	}
 
-	srv, err := web.New(ctx, webConf, bookManager, nil)
+	srv, err := web.New(ctx, webConf, bookManager)
 	if err != nil {
 		return fmt.Errorf("init http server: %w", err)
 	}
@@ -0,0 +1,27 @@
+package app
+
+import (
+	"context"
+	"time"
+
+	"github.com/avstrong/booking/internal/logger"
+)
+
+// runPeriodically calls job every interval until ctx is done.
+func runPeriodically(ctx context.Context, l *logger.Logger, name string, interval time.Duration, job func(ctx context.Context) error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			l.LogInfo("Worker %v stopped", name)
+
+			return
+		case <-ticker.C:
+			if err := job(ctx); err != nil {
+				l.LogErrorf("Worker %v failed: %v", name, err.Error())
+			}
+		}
+	}
+}
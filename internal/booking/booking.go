@@ -2,7 +2,6 @@ package booking
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"net/mail"
 	"time"
@@ -16,16 +15,46 @@ type idGenerator interface {
 
 type storageReader interface {
 	GetAvailabilities(ctx context.Context, properties []GetAvailabilityInput) ([]*RoomAvailability, error)
-	GetOrderByIdempotencyKey(ctx context.Context) (*Order, error)
+	GetOrder(ctx context.Context, id int) (*Order, error)
+	GetHotels(ctx context.Context, ids []string) ([]*Hotel, error)
+	ListHotels(ctx context.Context) ([]*Hotel, error)
+	// GetRoomTypes returns room types of the hotels.
+	GetRoomTypes(ctx context.Context, hotelIDs []string) ([]*RoomType, error)
+	// GetRates returns rates which exist for nights of the inputs.
+	GetRates(ctx context.Context, inputs []GetAvailabilityInput) ([]*RoomRate, error)
+	ListOrders(ctx context.Context, filter OrderFilter) ([]*Order, error)
+	// GetRestrictions returns stay restrictions which exist for dates of the inputs.
+	GetRestrictions(ctx context.Context, inputs []GetAvailabilityInput) ([]*StayRestriction, error)
+	// SearchAvailabilities returns stored availabilities matching the filter. Missing nights are not an error.
+	SearchAvailabilities(ctx context.Context, filter AvailabilityFilter) ([]*RoomAvailability, error)
 }
 
 type storageWriter interface {
 	BeginTransaction(ctx context.Context, level string) (context.Context, error)
 	CommitTransaction(ctx context.Context) error
 	RollbackTransaction(ctx context.Context) error
-	SaveRoomAvailabilities(ctx context.Context, availabilities []*RoomAvailability) error
+	// AdjustQuotas changes quota within the transaction. A night without availability counts as quota 0 and
+	// is created on commit. Commit fails with ErrConflict when any quota would become negative or differs
+	// from the expected one.
+	AdjustQuotas(ctx context.Context, changes []QuotaChange) error
+	SaveHotels(ctx context.Context, hotels []*Hotel) error
+	SaveRoomTypes(ctx context.Context, rooms []*RoomType) error
+	SaveRestrictions(ctx context.Context, restrictions []*StayRestriction) error
 	SaveEvent(ctx context.Context, event *Event) error
 	SaveOrder(ctx context.Context, order *Order) error
+	// RedeemPromoCodes records usages of promo codes within the transaction. Commit fails with
+	// a PromoLimitError when a code would be used more times than its limits allow.
+	RedeemPromoCodes(ctx context.Context, redemptions []PromoRedemption) error
+	// ReleasePromoCodes deletes usages of promo codes by the order within the transaction.
+	ReleasePromoCodes(ctx context.Context, orderID int) error
+	// ReserveIdempotencyKey atomically saves the in-progress record for a new, expired or failed key and
+	// reports whether it did. Otherwise the existing record is returned as is.
+	ReserveIdempotencyKey(ctx context.Context, record *IdempotencyRecord) (*IdempotencyRecord, bool, error)
+	// CompleteIdempotencyKey stores the result of the request within the transaction.
+	CompleteIdempotencyKey(ctx context.Context, key string, order *Order) error
+	FailIdempotencyKey(ctx context.Context, key string) error
+	// PurgeIdempotencyKeys deletes keys expired before the moment and returns their number.
+	PurgeIdempotencyKeys(ctx context.Context, before time.Time) (int, error)
 }
 
 type storage interface {
@@ -33,96 +62,215 @@ type storage interface {
 	storageWriter
 }
 
+// BoostStrategy calculates discounts for an order priced by nightly rates. It must not change the order:
+// the manager picks which strategies to combine by their rules and adds their discount line items.
 type BoostStrategy interface {
-	Apply(order *Order) error
+	Rules() BoostRules
+	Apply(order *Order) ([]LineItem, error)
+}
+
+// boostProvider resolves boost strategies, such as promo codes and loyalty discounts, for orders.
+type boostProvider interface {
+	// Strategies returns strategies for a booking request. Invalid promo codes are reported as an InputError.
+	Strategies(ctx context.Context, input *BookInput) ([]BoostStrategy, error)
+	// OrderStrategies returns strategies for re-pricing a booked order. Its promo codes are already checked,
+	// so they are not checked again, and codes which do not apply to the priced order are returned as rejected.
+	OrderStrategies(ctx context.Context, order *Order) ([]BoostStrategy, []RejectedBoost, error)
+}
+
+type Config struct {
+	L *logger.Logger
+	// HoldTTL is how long a held order keeps its rooms before it expires.
+	HoldTTL time.Duration
+	// CommitAttempts limits how many times an operation is repeated after ErrConflict.
+	CommitAttempts int
+	// CommitRetryDelay is multiplied by the attempt number to wait between attempts.
+	CommitRetryDelay time.Duration
+	// IdempotencyKeyTTL is how long a processed request is remembered by its idempotency key.
+	IdempotencyKeyTTL time.Duration
 }
 
 type Manager struct {
-	l           *logger.Logger
-	storage     storage
-	idGenerator idGenerator
+	l             *logger.Logger
+	conf          Config
+	storage       storage
+	idGenerator   idGenerator
+	exchangeRates exchangeRates
+	boosts        boostProvider
 }
 
-func New(l *logger.Logger, storage storage, idGenerator idGenerator) *Manager {
+// New creates a manager. Display currencies are not supported when exchangeRates is nil, and orders get
+// no boosts when boosts is nil.
+func New(conf Config, storage storage, idGenerator idGenerator, exchangeRates exchangeRates, boosts boostProvider) *Manager {
 	return &Manager{
-		l:           l,
-		storage:     storage,
-		idGenerator: idGenerator,
+		l:             conf.L,
+		conf:          conf,
+		storage:       storage,
+		idGenerator:   idGenerator,
+		exchangeRates: exchangeRates,
+		boosts:        boosts,
 	}
 }
 
-func (b *BookInput) validate() error {
-	inputErr := newInputError()
+func (b *BookInput) validate(hotels map[string]*Hotel) error {
+	inputErr := NewInputError()
 
 	if _, err := mail.ParseAddress(b.Payer.Email); err != nil {
-		inputErr.addError("payer.email", "provide valid email")
+		inputErr.AddError("payer.email", "provide valid email")
 	}
 
-	if len(b.Places) == 0 {
-		inputErr.addError("places", "provide at least one place")
+	validatePlaces(inputErr, b.Places, hotels)
+	validateDisplayCurrency(inputErr, b.DisplayCurrency)
+
+	if inputErr.FieldsCount() > 0 {
+		return inputErr
 	}
 
-	for _, place := range b.Places {
+	return nil
+}
+
+// validatePlaces checks dates against the local calendar of the hotels.
+func validatePlaces(inputErr *InputError, places []Place, hotels map[string]*Hotel) {
+	if len(places) == 0 {
+		inputErr.AddError("places", "provide at least one place")
+	}
+
+	for _, place := range places {
 		if place.HotelID == "" {
-			inputErr.addError("place.hotelID", "provide place.hotelID")
+			inputErr.AddError("place.hotelID", "provide place.hotelID")
+
+			continue
 		}
 
-		if place.RoomID == "" {
-			inputErr.addError("place.roomID", "provide place.roomID")
+		hotel, ok := hotels[place.HotelID]
+		if !ok {
+			inputErr.AddError("place.hotelID", fmt.Sprintf("hotel '%v' does not exist", place.HotelID))
+
+			continue
 		}
 
-		if place.From.Before(time.Now().UTC()) {
-			inputErr.addError("place.from", "place.from must not be in the past")
+		if !hotel.Active {
+			inputErr.AddError("place.hotelID", fmt.Sprintf("hotel '%v' is not active", place.HotelID))
 		}
 
-		if place.To.Before(time.Now().UTC()) {
-			inputErr.addError("place.from", "place.to must not be in the past")
+		validateRoom(inputErr, hotel, place.RoomID)
+		validateOccupancy(inputErr, hotel, place)
+
+		from, to := hotel.date(place.From), hotel.date(place.To)
+
+		if from.Before(hotel.today()) {
+			inputErr.AddError("place.from", "place.from must not be in the past")
 		}
 
-		if place.From.After(place.To) {
-			inputErr.addError("place.from", "place.from must be before place.to")
+		if !to.After(from) {
+			inputErr.AddError("place.to", "place.to must be at least one night after place.from")
 		}
 	}
+}
 
-	if inputErr.fieldsCount() > 0 {
-		return inputErr
+func validateRoom(inputErr *InputError, hotel *Hotel, roomID string) {
+	if roomID == "" {
+		inputErr.AddError("place.roomID", "provide place.roomID")
+
+		return
 	}
 
-	return nil
+	room := hotel.room(roomID)
+
+	switch {
+	case room == nil:
+		inputErr.AddError("place.roomID", fmt.Sprintf("room '%v' does not exist in hotel '%v'", roomID, hotel.ID))
+	case !room.Active:
+		inputErr.AddError("place.roomID", fmt.Sprintf("room '%v' is not active in hotel '%v'", roomID, hotel.ID))
+	}
 }
 
-func (b *BookInput) prepareDates() {
-	for idx := range b.Places {
-		b.Places[idx].From = b.Places[idx].From.Truncate(24 * time.Hour) //nolint:gomnd
-		b.Places[idx].To = b.Places[idx].To.Truncate(24 * time.Hour)     //nolint:gomnd
+// validateOccupancy checks the occupancy against the capacity of the room and the guest names against the occupancy.
+func validateOccupancy(inputErr *InputError, hotel *Hotel, place Place) {
+	occupancy := place.Occupancy
+
+	switch {
+	case occupancy.Adults < 0 || occupancy.Children < 0:
+		inputErr.AddError("place.occupancy", "adults and children must not be negative")
+	case occupancy.Adults == 0 && occupancy.Children > 0:
+		inputErr.AddError("place.occupancy", "children can not stay without adults")
+	}
+
+	if room := hotel.room(place.RoomID); room != nil && occupancy.Guests() > room.MaxOccupancy {
+		inputErr.AddError("place.occupancy", fmt.Sprintf(
+			"room '%v' in hotel '%v' takes at most %v guests", place.RoomID, hotel.ID, room.MaxOccupancy,
+		))
 	}
+
+	if len(place.Guests) > max(occupancy.Guests(), 1) {
+		inputErr.AddError("place.guests", "provide at most as many guests as the occupancy of the place")
+	}
+
+	for _, guest := range place.Guests {
+		if guest.FirstName == "" || guest.LastName == "" {
+			inputErr.AddError("place.guests", "provide first and last name of every guest")
+
+			break
+		}
+	}
+}
+
+func (b *BookInput) preparePlaces(hotels map[string]*Hotel) {
+	preparePlaces(b.Places, hotels)
 }
 
-func (m *Manager) buildOrder(ctx context.Context, input *BookInput) (*Order, *Event, error) {
+// preparePlaces turns stay dates into the hotel's local calendar dates, sets check-in and check-out moments
+// and fills in one adult for places without occupancy.
+func preparePlaces(places []Place, hotels map[string]*Hotel) {
+	for idx := range places {
+		hotel := hotels[places[idx].HotelID]
+
+		places[idx].Occupancy = places[idx].Occupancy.orDefault()
+		places[idx].From = hotel.date(places[idx].From)
+		places[idx].To = hotel.date(places[idx].To)
+		places[idx].CheckIn = hotel.at(places[idx].From, hotel.checkIn)
+		places[idx].CheckOut = hotel.at(places[idx].To, hotel.checkOut)
+	}
+}
+
+func availabilityKey(hotelID, roomID string, date time.Time) string {
+	return fmt.Sprintf("%s_%s_%s", hotelID, roomID, date.Format("2006-01-02"))
+}
+
+func (m *Manager) buildOrder(ctx context.Context, input *BookInput, hotels map[string]*Hotel) (*Order, *Event, error) {
 	id, err := m.idGenerator.GetID(ctx)
 	if err != nil {
 		return nil, nil, ErrNextID
 	}
 
-	//nolint:exhaustruct // price is only added for test
+	now := time.Now().UTC()
+
+	//nolint:exhaustruct // price is set by priceOrder
 	order := &Order{
-		ID: id,
+		ID:     id,
+		Status: OrderStatusPending,
 		Payer: Payer{
-			Email: input.Payer.Email,
+			Email: normalizeEmail(input.Payer.Email),
 		},
-		Places:    input.Places,
-		CreatedAt: time.Now().UTC(),
+		Places:     input.Places,
+		PromoCodes: input.PromoCodes,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+		Version:    1,
 	}
 
-	if input.BoostStrategies != nil {
-		for _, strategy := range input.BoostStrategies {
-			if err := strategy.Apply(order); err != nil {
-				return nil, nil, fmt.Errorf("apply strategy to order: %w", err)
-			}
-		}
+	if input.Hold {
+		holdExpiresAt := now.Add(m.conf.HoldTTL)
+
+		order.Status = OrderStatusHeld
+		order.HoldExpiresAt = &holdExpiresAt
+	}
+
+	if err := m.priceNewOrder(ctx, order, input, hotels); err != nil {
+		return nil, nil, err
 	}
 
-	event, err := m.buildEvent(ctx, order.ID)
+	event, err := m.buildEvent(ctx, order.ID, EventOrderCreated, "", order.Status)
 	if err != nil {
 		return nil, nil, fmt.Errorf("build event for order %v: %w", order.ID, err)
 	}
@@ -130,153 +278,269 @@ func (m *Manager) buildOrder(ctx context.Context, input *BookInput) (*Order, *Ev
 	return order, event, nil
 }
 
-func (m *Manager) buildEvent(ctx context.Context, orderID int) (*Event, error) {
+func (m *Manager) buildEvent(
+	ctx context.Context,
+	orderID int,
+	eventType EventType,
+	from, to OrderStatus,
+) (*Event, error) {
 	id, err := m.idGenerator.GetID(ctx)
 	if err != nil {
 		return nil, ErrNextID
 	}
 
 	return &Event{
-		ID:        id,
-		OrderID:   orderID,
-		CreatedAt: time.Now().UTC(),
+		ID:         id,
+		OrderID:    orderID,
+		Type:       eventType,
+		FromStatus: from,
+		ToStatus:   to,
+		CreatedAt:  time.Now().UTC(),
 	}, nil
 }
 
-func (m *Manager) getRoomAvailabilities(ctx context.Context, input *BookInput) ([]*RoomAvailability, error) {
-	req := make([]GetAvailabilityInput, 0, len(input.Places))
-
-	for _, property := range input.Places {
-		req = append(req, GetAvailabilityInput{
-			HotelID: property.HotelID,
-			RoomID:  property.RoomID,
-			From:    property.From,
-			To:      property.To,
-		})
+// inTransaction runs fn inside a storage transaction. The transaction is committed
+// when fn succeeds and rolled back when it returns an error or panics.
+func (m *Manager) inTransaction(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	ctx, err = m.storage.BeginTransaction(ctx, "READ COMMITTED")
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
 	}
 
-	availabilities, err := m.storage.GetAvailabilities(ctx, req)
+	defer func() {
+		if p := recover(); p != nil {
+			if err := m.storage.RollbackTransaction(ctx); err != nil {
+				m.l.LogErrorf("Could not rollback booking transaction after panic %v", p)
+			}
+
+			m.l.LogInfo("Transaction has been roll backed after panic")
+
+			panic(p)
+		}
+
+		if err != nil {
+			if rollbackErr := m.storage.RollbackTransaction(ctx); rollbackErr != nil {
+				m.l.LogErrorf("Could not rollback booking transaction after error %v", rollbackErr.Error())
+			}
+
+			m.l.LogInfo("Transaction has been roll backed after error")
+
+			return
+		}
+
+		if err = m.storage.CommitTransaction(ctx); err != nil {
+			m.l.LogErrorf("Could not commit booking transaction, err %v", err.Error())
+
+			err = fmt.Errorf("commit transaction: %w", err)
+
+			return
+		}
+
+		m.l.LogInfo("Transaction has been committed")
+	}()
+
+	return fn(ctx)
+}
+
+func (m *Manager) CreateOrder(ctx context.Context, input *BookInput) (*Order, error) {
+	requestFingerprint, err := input.fingerprint()
 	if err != nil {
-		return nil, fmt.Errorf("get availabilities from storage: %w", err)
+		return nil, fmt.Errorf("fingerprint request: %w", err)
 	}
 
-	return availabilities, nil
+	// A retry of a completed request is answered before validation, which could fail by now because the dates
+	// have passed or the hotel has been deactivated.
+	return m.idempotent(ctx, scopeCreateOrder, requestFingerprint, func(ctx context.Context) (order *Order, err error) {
+		hotels, err := m.getHotels(ctx, input.Places)
+		if err != nil {
+			return nil, fmt.Errorf("get hotels: %w", err)
+		}
+
+		if err := input.validate(hotels); err != nil {
+			return nil, err
+		}
+
+		input.preparePlaces(hotels)
+
+		err = m.retryOnConflict(ctx, func() error {
+			order, err = m.createOrder(ctx, input, hotels)
+
+			return err
+		})
+
+		return order, err
+	})
 }
 
-func (m *Manager) updateRoomAvailabilities(
-	input *BookInput,
-	availabilities []*RoomAvailability,
-) ([]*RoomAvailability, error) {
-	var updatedRoomAvailabilities []*RoomAvailability
+func (m *Manager) createOrder(ctx context.Context, input *BookInput, hotels map[string]*Hotel) (*Order, error) {
+	nights := countNights(input.Places)
 
-	availabilityMap := make(map[string]*RoomAvailability)
+	if err := joinBookingErrors(m.checkAvailability(ctx, nights), m.checkRestrictions(ctx, input.Places)); err != nil {
+		return nil, err
+	}
 
-	for _, availability := range availabilities {
-		key := fmt.Sprintf("%s_%s_%s", availability.HotelID, availability.RoomID, availability.Date.Format("2006-01-02"))
-		availabilityMap[key] = availability
+	order, event, err := m.buildOrder(ctx, input, hotels)
+	if err != nil {
+		return nil, fmt.Errorf("build order: %w", err)
 	}
 
-	for _, property := range input.Places {
-		currentDate := property.From
-		for !currentDate.After(property.To) {
-			key := fmt.Sprintf("%s_%s_%s", property.HotelID, property.RoomID, currentDate.Format("2006-01-02"))
-			if availability, ok := availabilityMap[key]; ok {
-				availability.Quota--
+	err = m.inTransaction(ctx, func(ctx context.Context) error {
+		if err := m.storage.SaveOrder(ctx, order); err != nil {
+			return fmt.Errorf("save order to storage: %w", err)
+		}
 
-				updatedRoomAvailabilities = append(updatedRoomAvailabilities, availability)
-				currentDate = currentDate.AddDate(0, 0, 1)
+		if err := m.storage.AdjustQuotas(ctx, quotaChanges(nights, -1)); err != nil {
+			return fmt.Errorf("take quotas in storage: %w", err)
+		}
 
-				continue
-			}
+		if err := m.storage.RedeemPromoCodes(ctx, order.promoRedemptions()); err != nil {
+			return fmt.Errorf("redeem promo codes in storage: %w", err)
+		}
 
-			return nil, fmt.Errorf(
-				"data are not the same. Check storage. Input %+v | RoomAvailabilities %+v: %w",
-				input,
-				availabilities,
-				ErrLogic,
-			)
+		if err := m.completeIdempotencyKey(ctx, order); err != nil {
+			return err
 		}
-	}
 
-	return updatedRoomAvailabilities, nil
-}
+		if err := m.storage.SaveEvent(ctx, event); err != nil {
+			return fmt.Errorf("save event to storage: %w", err)
+		}
 
-//nolint:funlen,cyclop // it's linear simple code
-func (m *Manager) CreateOrder(ctx context.Context, input *BookInput) (_ *Order, err error) {
-	if err := input.validate(); err != nil {
+		return nil
+	})
+	if err != nil {
 		return nil, err
 	}
 
-	order, err := m.storage.GetOrderByIdempotencyKey(ctx)
-	if err != nil && !errors.Is(err, ErrRecordNotFound) {
-		return nil, fmt.Errorf("get order by idempotency key: %w", err)
-	}
+	return order, nil
+}
+
+// ChangeOrderStatus moves the order to the given status. Cancellation and expiry also return
+// the booked quota to inventory.
+func (m *Manager) ChangeOrderStatus(ctx context.Context, id int, to OrderStatus) (*Order, error) {
+	if !to.valid() {
+		inputErr := NewInputError()
+		inputErr.AddError("status", fmt.Sprintf("unknown status '%v'", to))
 
-	if !errors.Is(err, ErrRecordNotFound) {
-		return order, nil
+		return nil, inputErr
 	}
 
-	input.prepareDates()
+	var order *Order
+
+	err := m.retryOnConflict(ctx, func() (err error) {
+		order, err = m.changeOrderStatus(ctx, id, to)
 
-	availabilities, err := m.getRoomAvailabilities(ctx, input)
+		return err
+	})
 	if err != nil {
-		return nil, fmt.Errorf("get availabilities: %w", err)
+		return nil, err
 	}
 
-	availabilities, err = m.updateRoomAvailabilities(input, availabilities)
+	return order, nil
+}
+
+func (m *Manager) changeOrderStatus(ctx context.Context, id int, to OrderStatus) (*Order, error) {
+	order, err := m.storage.GetOrder(ctx, id)
 	if err != nil {
-		return nil, fmt.Errorf("update availabilities: %w", err)
+		return nil, fmt.Errorf("get order %v: %w", id, err)
 	}
 
-	order, event, err := m.buildOrder(ctx, input)
-	if err != nil {
-		return nil, fmt.Errorf("build order: %w", err)
+	now := time.Now().UTC()
+
+	if to == OrderStatusConfirmed && order.holdExpired(now) {
+		return nil, fmt.Errorf("confirm order %v: %w", id, ErrHoldExpired)
 	}
 
-	ctx, err = m.storage.BeginTransaction(ctx, "READ COMMITTED")
+	// Only the hold time running out expires an order; a live hold is cancelled instead.
+	if to == OrderStatusExpired && order.Status == OrderStatusHeld && !order.holdExpired(now) {
+		return nil, fmt.Errorf("expire order %v: %w", id, ErrHoldActive)
+	}
+
+	from, err := order.transition(to)
 	if err != nil {
-		return nil, fmt.Errorf("begin transaction: %w", err)
+		return nil, fmt.Errorf("change status of order %v: %w", id, err)
 	}
 
-	defer func() {
-		if p := recover(); p != nil {
-			if err = m.storage.RollbackTransaction(ctx); err != nil {
-				m.l.LogErrorf("Could not rollback booking transaction after panic %v", p)
-			}
+	order.UpdatedAt = now
+	order.HoldExpiresAt = nil
+	order.Version++
 
-			m.l.LogInfo("Transaction has been roll backed after panic")
+	var changes []QuotaChange
 
-			panic(p)
-		}
+	if to.releasesInventory() {
+		order.CancelledAt = &now
+		changes = quotaChanges(countNights(order.Places), 1)
+	}
 
-		if err != nil {
-			if err = m.storage.RollbackTransaction(ctx); err != nil {
-				m.l.LogErrorf("Could not rollback booking transaction after error %v", err.Error())
-			}
+	event, err := m.buildEvent(ctx, order.ID, EventOrderStatusChanged, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("build event for order %v: %w", order.ID, err)
+	}
 
-			m.l.LogInfo("Transaction has been roll backed after error")
+	err = m.inTransaction(ctx, func(ctx context.Context) error {
+		if err := m.storage.SaveOrder(ctx, order); err != nil {
+			return fmt.Errorf("save order to storage: %w", err)
+		}
 
-			return
+		if err := m.storage.AdjustQuotas(ctx, changes); err != nil {
+			return fmt.Errorf("release quotas in storage: %w", err)
 		}
 
-		if err = m.storage.CommitTransaction(ctx); err != nil {
-			m.l.LogErrorf("Could not commit booking transaction, err %v", err.Error())
+		if to.releasesInventory() && len(order.PromoCodes) > 0 {
+			if err := m.storage.ReleasePromoCodes(ctx, order.ID); err != nil {
+				return fmt.Errorf("release promo codes in storage: %w", err)
+			}
 		}
 
-		m.l.LogInfo("Transaction has been committed")
-	}()
+		if err := m.storage.SaveEvent(ctx, event); err != nil {
+			return fmt.Errorf("save event to storage: %w", err)
+		}
 
-	if err = m.storage.SaveOrder(ctx, order); err != nil {
-		return nil, fmt.Errorf("save order to storage: %w", err)
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	if err = m.storage.SaveRoomAvailabilities(ctx, availabilities); err != nil {
-		return nil, fmt.Errorf("save room availabilities to storage: %w", err)
+	return order, nil
+}
+
+// CancelOrder cancels the order and returns the quota of every booked night back to inventory.
+func (m *Manager) CancelOrder(ctx context.Context, id int) (*Order, error) {
+	return m.ChangeOrderStatus(ctx, id, OrderStatusCancelled)
+}
+
+// ConfirmOrder confirms a pending order, or turns a held order into a booking unless its hold has already expired.
+func (m *Manager) ConfirmOrder(ctx context.Context, id int) (*Order, error) {
+	return m.ChangeOrderStatus(ctx, id, OrderStatusConfirmed)
+}
+
+// ReleaseExpiredHolds expires held orders whose hold time is over and returns their quota to inventory.
+// It returns the number of released orders.
+func (m *Manager) ReleaseExpiredHolds(ctx context.Context) (int, error) {
+	//nolint:exhaustruct
+	held, err := m.storage.ListOrders(ctx, OrderFilter{Statuses: []OrderStatus{OrderStatusHeld}})
+	if err != nil {
+		return 0, fmt.Errorf("list held orders from storage: %w", err)
 	}
 
-	if err = m.storage.SaveEvent(ctx, event); err != nil {
-		return nil, fmt.Errorf("save event to storage: %w", err)
+	now := time.Now().UTC()
+
+	var released int
+
+	for _, order := range held {
+		if !order.holdExpired(now) {
+			continue
+		}
+
+		if _, err := m.ChangeOrderStatus(ctx, order.ID, OrderStatusExpired); err != nil {
+			// The order could have been confirmed or cancelled in the meantime.
+			m.l.LogErrorf("Could not release expired hold of order %v: %v", order.ID, err.Error())
+
+			continue
+		}
+
+		released++
 	}
 
-	return order, nil
+	return released, nil
 }
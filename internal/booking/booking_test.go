@@ -0,0 +1,199 @@
+package booking_test
+
+import (
+	"context"
+	"io"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/avstrong/booking/internal/booking"
+	"github.com/avstrong/booking/internal/boost"
+	"github.com/avstrong/booking/internal/idgen/simple"
+	"github.com/avstrong/booking/internal/logger"
+	"github.com/avstrong/booking/internal/money"
+	"github.com/avstrong/booking/internal/storage/memory"
+)
+
+const (
+	quota  = 5
+	nights = 3
+)
+
+// startDate returns a date far enough ahead to be bookable.
+func startDate() time.Time {
+	return time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, 30)
+}
+
+// newManager creates a manager without boosts over storage of newDB.
+func newManager(t *testing.T, conf booking.Config, hotel *booking.Hotel, from time.Time) (*booking.Manager, *memory.DB) {
+	t.Helper()
+
+	db := newDB(t, hotel, from)
+	conf.L = logger.New(log.New(io.Discard, "", 0))
+
+	return booking.New(conf, db, simple.New(), nil, nil), db
+}
+
+// newBoostedManager creates a manager over the storage which resolves promo codes and loyalty discounts
+// of boost.Manager.
+func newBoostedManager(db *memory.DB, conf boost.Config) *booking.Manager {
+	//nolint:exhaustruct
+	bookConf := booking.Config{L: logger.New(log.New(io.Discard, "", 0)), IdempotencyKeyTTL: time.Hour}
+
+	return booking.New(bookConf, db, simple.New(), nil, boost.New(conf, db))
+}
+
+// savePromo adds the promo code to the catalog. It is valid from yesterday until tomorrow.
+func savePromo(t *testing.T, db *memory.DB, promo *boost.Promo) {
+	t.Helper()
+
+	promo.ValidFrom = time.Now().UTC().AddDate(0, 0, -1)
+	promo.ValidThrough = time.Now().UTC().AddDate(0, 0, 1)
+
+	ctx, err := db.BeginTransaction(context.Background(), "")
+	if err != nil {
+		t.Fatalf("begin transaction: %v", err)
+	}
+
+	if err := db.SavePromoCodes(ctx, []*boost.Promo{promo}); err != nil {
+		t.Fatalf("save promo codes: %v", err)
+	}
+
+	if err := db.CommitTransaction(ctx); err != nil {
+		t.Fatalf("commit transaction: %v", err)
+	}
+}
+
+// newDB creates memory storage with the hotel and its "lux" room type for up to four guests. Every night
+// from the date is sold at 5000 RUB for two guests, 4500 RUB for one and 1500 RUB for every extra bed.
+func newDB(t *testing.T, hotel *booking.Hotel, from time.Time) *memory.DB {
+	t.Helper()
+
+	db := memory.New(memory.Config{L: logger.New(log.New(io.Discard, "", 0))})
+
+	ctx, err := db.BeginTransaction(context.Background(), "")
+	if err != nil {
+		t.Fatalf("begin transaction: %v", err)
+	}
+
+	availabilities := make([]*booking.RoomAvailability, 0, nights)
+	rates := make([]*booking.RoomRate, 0, nights)
+	singlePrice := money.FromMajor(4500, "RUB")
+	extraBedPrice := money.FromMajor(1500, "RUB")
+
+	for i := 0; i < nights; i++ {
+		availabilities = append(availabilities, &booking.RoomAvailability{
+			HotelID: hotel.ID,
+			RoomID:  "lux",
+			Date:    from.AddDate(0, 0, i),
+			Quota:   quota,
+		})
+		rates = append(rates, &booking.RoomRate{
+			HotelID:       hotel.ID,
+			RoomID:        "lux",
+			Date:          from.AddDate(0, 0, i),
+			Price:         money.FromMajor(5000, "RUB"),
+			SinglePrice:   &singlePrice,
+			ExtraBedPrice: &extraBedPrice,
+		})
+	}
+
+	if err := db.SaveRoomAvailabilities(ctx, availabilities); err != nil {
+		t.Fatalf("save room availabilities: %v", err)
+	}
+
+	if err := db.SaveRates(ctx, rates); err != nil {
+		t.Fatalf("save rates: %v", err)
+	}
+
+	if err := db.SaveHotels(ctx, []*booking.Hotel{hotel}); err != nil {
+		t.Fatalf("save hotels: %v", err)
+	}
+
+	//nolint:exhaustruct
+	room := &booking.RoomType{HotelID: hotel.ID, ID: "lux", Name: "Lux", MaxOccupancy: 4, Active: true}
+	if err := db.SaveRoomTypes(ctx, []*booking.RoomType{room}); err != nil {
+		t.Fatalf("save room types: %v", err)
+	}
+
+	if err := db.CommitTransaction(ctx); err != nil {
+		t.Fatalf("commit transaction: %v", err)
+	}
+
+	return db
+}
+
+// saveHotel adds the hotel to storage as is, without validation of the catalog.
+func saveHotel(t *testing.T, db *memory.DB, hotel *booking.Hotel) {
+	t.Helper()
+
+	ctx, err := db.BeginTransaction(context.Background(), "")
+	if err != nil {
+		t.Fatalf("begin transaction: %v", err)
+	}
+
+	if err := db.SaveHotels(ctx, []*booking.Hotel{hotel}); err != nil {
+		t.Fatalf("save hotels: %v", err)
+	}
+
+	if err := db.CommitTransaction(ctx); err != nil {
+		t.Fatalf("commit transaction: %v", err)
+	}
+}
+
+// newHotel returns an active hotel in UTC.
+func newHotel() *booking.Hotel {
+	//nolint:exhaustruct
+	return &booking.Hotel{ID: "reddison", Name: "Reddison", TimeZone: "UTC", Active: true}
+}
+
+// luxPlace returns a stay of two adults in the "lux" room of the hotel for the number of nights from the date.
+func luxPlace(from time.Time, n int) booking.Place {
+	//nolint:exhaustruct
+	return booking.Place{
+		HotelID:   "reddison",
+		RoomID:    "lux",
+		From:      from,
+		To:        from.AddDate(0, 0, n),
+		Occupancy: booking.Occupancy{Adults: 2, Children: 0},
+	}
+}
+
+// createOrder books the place for the guest with the idempotency key.
+func createOrder(t *testing.T, manager *booking.Manager, key string, place booking.Place) *booking.Order {
+	t.Helper()
+
+	//nolint:exhaustruct
+	order, err := manager.CreateOrder(booking.NewContextWithIdempotencyKey(context.Background(), key), &booking.BookInput{
+		Payer:  booking.Payer{Email: "guest@mail.ru"},
+		Places: []booking.Place{place},
+	})
+	if err != nil {
+		t.Fatalf("create order: %v", err)
+	}
+
+	return order
+}
+
+// quotas returns quota left for every night on sale in newDB.
+func quotas(t *testing.T, db *memory.DB, from time.Time) []int {
+	t.Helper()
+
+	availabilities, err := db.GetRoomAvailabilities(context.Background(), []booking.GetAvailabilityInput{{
+		HotelID: "reddison",
+		RoomID:  "lux",
+		From:    from,
+		To:      from.AddDate(0, 0, nights),
+	}})
+	if err != nil {
+		t.Fatalf("get room availabilities: %v", err)
+	}
+
+	result := make([]int, 0, len(availabilities))
+	for _, availability := range availabilities {
+		result = append(result, availability.Quota)
+	}
+
+	return result
+}
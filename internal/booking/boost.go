@@ -0,0 +1,330 @@
+package booking
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/avstrong/booking/internal/money"
+)
+
+// maxBoostStrategies limits strategies of one order, since every combination of them is evaluated.
+const maxBoostStrategies = 12
+
+// BoostRules tell how a strategy combines with others.
+type BoostRules struct {
+	StrategyType string
+	StrategyCode string
+	// Priority decides between combinations which give the same discount: higher priorities win.
+	Priority int
+	// Strategies of one group do not stack, at most one of them is applied. Strategies without a group
+	// stack with any strategy.
+	Group string
+	// Exclusive strategies are applied alone.
+	Exclusive bool
+	// MaxDiscountPercentage caps the total discount of a combination with the strategy, in percent of
+	// the base price of the order. Zero means no cap other than the base price itself.
+	MaxDiscountPercentage float64
+}
+
+// RejectedBoost is a strategy which was not applied to the order.
+type RejectedBoost struct {
+	StrategyType string `json:"strategy_type"`
+	StrategyCode string `json:"strategy_code,omitempty"`
+	Reason       string `json:"reason"`
+}
+
+type boostCandidate struct {
+	rules BoostRules
+	items []LineItem
+	// discount is the positive amount of the discount items.
+	discount money.Money
+}
+
+func (c *boostCandidate) name() string {
+	if c.rules.StrategyCode == "" {
+		return c.rules.StrategyType
+	}
+
+	return fmt.Sprintf("%v '%v'", c.rules.StrategyType, c.rules.StrategyCode)
+}
+
+// boostCombination is a set of candidates given as a bit mask.
+type boostCombination struct {
+	mask     int
+	discount money.Money
+	// capped is the part of the discount over the cap.
+	capped   money.Money
+	priority int
+	perks    int
+}
+
+// boostStrategies returns strategies for the booking request, or none when the manager has no boosts.
+func (m *Manager) boostStrategies(ctx context.Context, input *BookInput) ([]BoostStrategy, error) {
+	if m.boosts == nil {
+		return nil, nil
+	}
+
+	strategies, err := m.boosts.Strategies(ctx, input)
+	if err != nil {
+		return nil, fmt.Errorf("get boost strategies: %w", err)
+	}
+
+	return strategies, nil
+}
+
+// orderBoostStrategies returns strategies for re-pricing the booked order and its promo codes which no longer
+// apply, or nothing when the manager has no boosts.
+func (m *Manager) orderBoostStrategies(ctx context.Context, order *Order) ([]BoostStrategy, []RejectedBoost, error) {
+	if m.boosts == nil {
+		return nil, nil, nil
+	}
+
+	strategies, rejected, err := m.boosts.OrderStrategies(ctx, order)
+	if err != nil {
+		return nil, nil, fmt.Errorf("get boost strategies of order %v: %w", order.ID, err)
+	}
+
+	return strategies, rejected, nil
+}
+
+// applyBoosts evaluates every legal combination of the strategies on the priced order and applies the one
+// with the biggest discount. Ties are broken by the total priority, then by the number of perks and then by
+// the order of strategies sorted by priority, type and code, so the result does not depend on the order
+// of the input.
+func (o *Order) applyBoosts(strategies []BoostStrategy) error {
+	o.RejectedBoosts = nil
+
+	if len(strategies) > maxBoostStrategies {
+		return fmt.Errorf("%v strategies of at most %v: %w", len(strategies), maxBoostStrategies, ErrTooManyBoosts)
+	}
+
+	candidates, err := o.boostCandidates(strategies)
+	if err != nil {
+		return err
+	}
+
+	base, err := o.basePrice()
+	if err != nil {
+		return err
+	}
+
+	var best *boostCombination
+
+	for mask := 0; mask < 1<<len(candidates); mask++ {
+		combination, err := evaluateBoosts(candidates, mask, base)
+		if err != nil {
+			return err
+		}
+
+		if combination != nil && combination.better(best) {
+			best = combination
+		}
+	}
+
+	for idx, candidate := range candidates {
+		if best.mask&(1<<idx) != 0 {
+			o.LineItems = append(o.LineItems, candidate.items...)
+
+			continue
+		}
+
+		o.RejectedBoosts = append(o.RejectedBoosts, RejectedBoost{
+			StrategyType: candidate.rules.StrategyType,
+			StrategyCode: candidate.rules.StrategyCode,
+			Reason:       rejectionReason(candidates, best.mask, idx),
+		})
+	}
+
+	if best.capped.Amount > 0 {
+		//nolint:exhaustruct // the cap is for the whole order
+		o.LineItems = append(o.LineItems, LineItem{
+			Type:   LineItemDiscountCap,
+			Amount: best.capped,
+		})
+	}
+
+	if err := o.total(); err != nil {
+		return fmt.Errorf("total order with discounts: %w", err)
+	}
+
+	return nil
+}
+
+func (o *Order) boostCandidates(strategies []BoostStrategy) ([]*boostCandidate, error) {
+	candidates := make([]*boostCandidate, 0, len(strategies))
+
+	for _, strategy := range strategies {
+		items, err := strategy.Apply(o)
+		if err != nil {
+			return nil, fmt.Errorf("apply strategy to order: %w", err)
+		}
+
+		amounts := make([]money.Money, 0, len(items))
+		for _, item := range items {
+			amounts = append(amounts, item.Amount)
+		}
+
+		discount, err := money.Sum(amounts...)
+		if err != nil {
+			return nil, fmt.Errorf("sum up discounts of strategy: %w", err)
+		}
+
+		if discount.Currency == "" {
+			discount = money.New(0, o.Price.Currency)
+		}
+
+		candidates = append(candidates, &boostCandidate{
+			rules:    strategy.Rules(),
+			items:    items,
+			discount: discount.Neg(),
+		})
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i].rules, candidates[j].rules
+		if a.Priority != b.Priority {
+			return a.Priority > b.Priority
+		}
+
+		if a.StrategyType != b.StrategyType {
+			return a.StrategyType < b.StrategyType
+		}
+
+		return a.StrategyCode < b.StrategyCode
+	})
+
+	return candidates, nil
+}
+
+// basePrice is the sum of night items, which discount caps are taken of.
+func (o *Order) basePrice() (money.Money, error) {
+	base := money.New(0, o.Price.Currency)
+
+	for _, item := range o.LineItems {
+		if item.Type != LineItemNight {
+			continue
+		}
+
+		var err error
+
+		if base, err = base.Add(item.Amount); err != nil {
+			return money.Money{}, fmt.Errorf("sum up nights: %w", err)
+		}
+	}
+
+	return base, nil
+}
+
+// evaluateBoosts returns the combination of the mask or nil if its strategies can not be combined.
+func evaluateBoosts(candidates []*boostCandidate, mask int, base money.Money) (*boostCombination, error) {
+	combination := &boostCombination{
+		mask:     mask,
+		discount: money.New(0, base.Currency),
+		capped:   money.New(0, base.Currency),
+		priority: 0,
+		perks:    0,
+	}
+
+	var (
+		members  int
+		excluded bool
+	)
+
+	// Discounts never take more than the base price.
+	limit := base
+
+	groups := make(map[string]bool)
+
+	for idx, candidate := range candidates {
+		if mask&(1<<idx) == 0 {
+			continue
+		}
+
+		members++
+
+		if candidate.rules.Exclusive {
+			excluded = true
+		}
+
+		if group := candidate.rules.Group; group != "" {
+			if groups[group] {
+				return nil, nil
+			}
+
+			groups[group] = true
+		}
+
+		if candidate.rules.MaxDiscountPercentage > 0 {
+			if capAmount := base.Percent(candidate.rules.MaxDiscountPercentage); capAmount.Amount < limit.Amount {
+				limit = capAmount
+			}
+		}
+
+		var err error
+
+		if combination.discount, err = combination.discount.Add(candidate.discount); err != nil {
+			return nil, fmt.Errorf("sum up discounts: %w", err)
+		}
+
+		combination.priority += candidate.rules.Priority
+
+		for _, item := range candidate.items {
+			if item.Type == LineItemPerk {
+				combination.perks++
+			}
+		}
+	}
+
+	if excluded && members > 1 {
+		return nil, nil
+	}
+
+	if combination.discount.Amount > limit.Amount {
+		combination.capped.Amount = combination.discount.Amount - limit.Amount
+		combination.discount = limit
+	}
+
+	return combination, nil
+}
+
+func (c *boostCombination) better(than *boostCombination) bool {
+	if than == nil {
+		return true
+	}
+
+	if c.discount.Amount != than.discount.Amount {
+		return c.discount.Amount > than.discount.Amount
+	}
+
+	if c.priority != than.priority {
+		return c.priority > than.priority
+	}
+
+	return c.perks > than.perks
+}
+
+// rejectionReason explains why the candidate is not in the applied combination.
+func rejectionReason(candidates []*boostCandidate, mask, rejected int) string {
+	candidate := candidates[rejected]
+
+	if candidate.rules.Exclusive && mask != 0 {
+		return "exclusive strategy does not give a bigger discount than the applied strategies"
+	}
+
+	for idx, applied := range candidates {
+		if mask&(1<<idx) == 0 {
+			continue
+		}
+
+		if applied.rules.Exclusive {
+			return fmt.Sprintf("%v is exclusive", applied.name())
+		}
+
+		if candidate.rules.Group != "" && applied.rules.Group == candidate.rules.Group {
+			return fmt.Sprintf("%v of the same group '%v' is applied instead", applied.name(), candidate.rules.Group)
+		}
+	}
+
+	return "does not increase the discount"
+}
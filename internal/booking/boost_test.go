@@ -0,0 +1,229 @@
+package booking_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"slices"
+	"testing"
+
+	"github.com/avstrong/booking/internal/booking"
+	"github.com/avstrong/booking/internal/idgen/simple"
+	"github.com/avstrong/booking/internal/logger"
+	"github.com/avstrong/booking/internal/money"
+)
+
+// fakeBoost discounts the order by a percentage of its price.
+type fakeBoost struct {
+	rules   booking.BoostRules
+	percent float64
+}
+
+func (b *fakeBoost) Rules() booking.BoostRules {
+	return b.rules
+}
+
+func (b *fakeBoost) Apply(order *booking.Order) ([]booking.LineItem, error) {
+	//nolint:exhaustruct
+	return []booking.LineItem{{
+		Type:         booking.LineItemDiscount,
+		StrategyType: b.rules.StrategyType,
+		StrategyCode: b.rules.StrategyCode,
+		Amount:       order.Price.Percent(b.percent).Neg(),
+	}}, nil
+}
+
+// fakeBoosts gives the same strategies to every order.
+type fakeBoosts []booking.BoostStrategy
+
+func (b fakeBoosts) Strategies(context.Context, *booking.BookInput) ([]booking.BoostStrategy, error) {
+	return b, nil
+}
+
+func (b fakeBoosts) OrderStrategies(context.Context, *booking.Order) ([]booking.BoostStrategy, []booking.RejectedBoost, error) {
+	return b, nil, nil
+}
+
+func boostOf(code string, percent float64, rules booking.BoostRules) *fakeBoost {
+	rules.StrategyType = "fake"
+	rules.StrategyCode = code
+
+	return &fakeBoost{rules: rules, percent: percent}
+}
+
+// quoteWithBoosts quotes two nights, which are 10000 RUB before discounts.
+func quoteWithBoosts(t *testing.T, strategies ...booking.BoostStrategy) (*booking.Quote, error) {
+	t.Helper()
+
+	from := startDate()
+	//nolint:exhaustruct
+	conf := booking.Config{L: logger.New(log.New(io.Discard, "", 0))}
+	manager := booking.New(conf, newDB(t, newHotel(), from), simple.New(), nil, fakeBoosts(strategies))
+
+	//nolint:exhaustruct
+	return manager.Quote(context.Background(), &booking.BookInput{
+		Payer:  booking.Payer{Email: "guest@mail.ru"},
+		Places: []booking.Place{luxPlace(from, 2)},
+	})
+}
+
+func TestManager_QuoteCombinesBoosts(t *testing.T) {
+	t.Parallel()
+
+	//nolint:exhaustruct
+	tests := []struct {
+		name       string
+		strategies []booking.BoostStrategy
+		applied    []string
+		rejected   []string
+		price      int64
+		capped     int64
+	}{
+		{
+			name:       "strategies without groups stack",
+			strategies: []booking.BoostStrategy{boostOf("a", 10, booking.BoostRules{}), boostOf("b", 5, booking.BoostRules{})},
+			applied:    []string{"a", "b"},
+			price:      8500,
+		},
+		{
+			name: "exclusive strategy with a bigger discount is applied alone",
+			strategies: []booking.BoostStrategy{
+				boostOf("a", 10, booking.BoostRules{}),
+				boostOf("b", 5, booking.BoostRules{}),
+				boostOf("x", 20, booking.BoostRules{Exclusive: true}),
+			},
+			applied:  []string{"x"},
+			rejected: []string{"a", "b"},
+			price:    8000,
+		},
+		{
+			name: "exclusive strategy with a smaller discount is rejected",
+			strategies: []booking.BoostStrategy{
+				boostOf("a", 10, booking.BoostRules{}),
+				boostOf("b", 5, booking.BoostRules{}),
+				boostOf("x", 12, booking.BoostRules{Exclusive: true}),
+			},
+			applied:  []string{"a", "b"},
+			rejected: []string{"x"},
+			price:    8500,
+		},
+		{
+			name: "one strategy of a group is applied",
+			strategies: []booking.BoostStrategy{
+				boostOf("a", 10, booking.BoostRules{Group: "season"}),
+				boostOf("b", 15, booking.BoostRules{Group: "season"}),
+				boostOf("c", 5, booking.BoostRules{}),
+			},
+			applied:  []string{"b", "c"},
+			rejected: []string{"a"},
+			price:    8000,
+		},
+		{
+			name:       "cap clips the discount",
+			strategies: []booking.BoostStrategy{boostOf("a", 30, booking.BoostRules{MaxDiscountPercentage: 20})},
+			applied:    []string{"a"},
+			price:      8000,
+			capped:     1000,
+		},
+		{
+			name: "tie goes to the higher priority",
+			strategies: []booking.BoostStrategy{
+				boostOf("a", 10, booking.BoostRules{Group: "season"}),
+				boostOf("b", 10, booking.BoostRules{Group: "season", Priority: 5}),
+			},
+			applied:  []string{"b"},
+			rejected: []string{"a"},
+			price:    9000,
+		},
+		{
+			name: "tie of priorities goes to the first code",
+			strategies: []booking.BoostStrategy{
+				boostOf("b", 10, booking.BoostRules{Group: "season"}),
+				boostOf("a", 10, booking.BoostRules{Group: "season"}),
+			},
+			applied:  []string{"a"},
+			rejected: []string{"b"},
+			price:    9000,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			quote, err := quoteWithBoosts(t, tt.strategies...)
+			if err != nil {
+				t.Fatalf("quote: %v", err)
+			}
+
+			var (
+				applied []string
+				capped  int64
+			)
+
+			for _, item := range quote.LineItems {
+				switch item.Type {
+				case booking.LineItemDiscount:
+					applied = append(applied, item.StrategyCode)
+				case booking.LineItemDiscountCap:
+					capped += item.Amount.Amount
+				}
+			}
+
+			rejected := make([]string, 0, len(quote.RejectedBoosts))
+			for _, boost := range quote.RejectedBoosts {
+				rejected = append(rejected, boost.StrategyCode)
+			}
+
+			slices.Sort(applied)
+			slices.Sort(rejected)
+
+			if !slices.Equal(applied, tt.applied) {
+				t.Errorf("applied %v, expected %v", applied, tt.applied)
+			}
+
+			if !slices.Equal(rejected, tt.rejected) {
+				t.Errorf("rejected %v, expected %v", rejected, tt.rejected)
+			}
+
+			if want := money.FromMajor(tt.price, "RUB"); quote.Price != want {
+				t.Errorf("price is %v, expected %v", quote.Price, want)
+			}
+
+			if want := money.FromMajor(tt.capped, "RUB").Amount; capped != want {
+				t.Errorf("capped %v, expected %v", capped, want)
+			}
+		})
+	}
+}
+
+func TestManager_QuoteLimitsBoosts(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		strategies int
+		wantErr    bool
+	}{
+		{strategies: 12, wantErr: false},
+		{strategies: 13, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.strategies), func(t *testing.T) {
+			t.Parallel()
+
+			strategies := make([]booking.BoostStrategy, 0, tt.strategies)
+			for i := 0; i < tt.strategies; i++ {
+				//nolint:exhaustruct
+				strategies = append(strategies, boostOf(fmt.Sprint(i), 1, booking.BoostRules{}))
+			}
+
+			_, err := quoteWithBoosts(t, strategies...)
+			if tooMany := errors.Is(err, booking.ErrTooManyBoosts); tooMany != tt.wantErr || (!tt.wantErr && err != nil) {
+				t.Errorf("quote with %v strategies got %v", tt.strategies, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,251 @@
+package booking
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+func (h *Hotel) validate() error {
+	inputErr := NewInputError()
+
+	if h.ID == "" {
+		inputErr.AddError("id", "provide id")
+	}
+
+	if h.Name == "" {
+		inputErr.AddError("name", "provide name")
+	}
+
+	if _, err := time.LoadLocation(h.TimeZone); h.TimeZone == "" || err != nil {
+		inputErr.AddError("time_zone", "provide IANA time zone name, e.g. Europe/Moscow")
+	}
+
+	if _, err := parseClock(h.CheckInTime); h.CheckInTime != "" && err != nil {
+		inputErr.AddError("check_in_time", "provide time of day in 15:04 format")
+	}
+
+	if _, err := parseClock(h.CheckOutTime); h.CheckOutTime != "" && err != nil {
+		inputErr.AddError("check_out_time", "provide time of day in 15:04 format")
+	}
+
+	for idx := range h.Taxes {
+		if err := h.Taxes[idx].validate(); err != nil {
+			inputErr.AddError("taxes", err.Error())
+		}
+	}
+
+	if inputErr.FieldsCount() > 0 {
+		return inputErr
+	}
+
+	return nil
+}
+
+func (r *RoomType) validate() error {
+	inputErr := NewInputError()
+
+	if r.ID == "" {
+		inputErr.AddError("id", "provide id")
+	}
+
+	if r.Name == "" {
+		inputErr.AddError("name", "provide name")
+	}
+
+	if r.MaxOccupancy < 1 {
+		inputErr.AddError("max_occupancy", "max_occupancy must be at least 1")
+	}
+
+	if inputErr.FieldsCount() > 0 {
+		return inputErr
+	}
+
+	return nil
+}
+
+// GetHotel returns a hotel of the catalog.
+func (m *Manager) GetHotel(ctx context.Context, id string) (*Hotel, error) {
+	hotels, err := m.storage.GetHotels(ctx, []string{id})
+	if err != nil {
+		return nil, fmt.Errorf("get hotels from storage: %w", err)
+	}
+
+	if len(hotels) == 0 {
+		return nil, fmt.Errorf("hotel %v: %w", id, ErrRecordNotFound)
+	}
+
+	return hotels[0], nil
+}
+
+func (m *Manager) ListHotels(ctx context.Context) ([]*Hotel, error) {
+	hotels, err := m.storage.ListHotels(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list hotels in storage: %w", err)
+	}
+
+	return hotels, nil
+}
+
+// CreateHotel adds a hotel to the catalog. Its rooms are added separately.
+func (m *Manager) CreateHotel(ctx context.Context, hotel *Hotel) (*Hotel, error) {
+	if err := hotel.validate(); err != nil {
+		return nil, err
+	}
+
+	if _, err := m.GetHotel(ctx, hotel.ID); err == nil {
+		return nil, fmt.Errorf("hotel %v: %w", hotel.ID, ErrAlreadyExists)
+	}
+
+	if err := m.saveHotel(ctx, hotel); err != nil {
+		return nil, err
+	}
+
+	return hotel, nil
+}
+
+// UpdateHotel replaces settings of a hotel. Existing orders keep their dates and prices.
+func (m *Manager) UpdateHotel(ctx context.Context, id string, hotel *Hotel) (*Hotel, error) {
+	hotel.ID = id
+
+	if err := hotel.validate(); err != nil {
+		return nil, err
+	}
+
+	if _, err := m.GetHotel(ctx, id); err != nil {
+		return nil, err
+	}
+
+	if err := m.saveHotel(ctx, hotel); err != nil {
+		return nil, err
+	}
+
+	return hotel, nil
+}
+
+// DeactivateHotel stops sales of a hotel. Hotels are not removed from the catalog because orders refer to them.
+func (m *Manager) DeactivateHotel(ctx context.Context, id string) (*Hotel, error) {
+	hotel, err := m.GetHotel(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	hotel.Active = false
+
+	if err := m.saveHotel(ctx, hotel); err != nil {
+		return nil, err
+	}
+
+	return hotel, nil
+}
+
+func (m *Manager) saveHotel(ctx context.Context, hotel *Hotel) error {
+	return m.inTransaction(ctx, func(ctx context.Context) error {
+		if err := m.storage.SaveHotels(ctx, []*Hotel{hotel}); err != nil {
+			return fmt.Errorf("save hotel to storage: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// GetRoomType returns a room type of a hotel.
+func (m *Manager) GetRoomType(ctx context.Context, hotelID, id string) (*RoomType, error) {
+	rooms, err := m.ListRoomTypes(ctx, hotelID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, room := range rooms {
+		if room.ID == id {
+			return room, nil
+		}
+	}
+
+	return nil, fmt.Errorf("room %v in hotel %v: %w", id, hotelID, ErrRecordNotFound)
+}
+
+// ListRoomTypes returns room types of a hotel. The hotel must exist.
+func (m *Manager) ListRoomTypes(ctx context.Context, hotelID string) ([]*RoomType, error) {
+	if _, err := m.GetHotel(ctx, hotelID); err != nil {
+		return nil, err
+	}
+
+	rooms, err := m.storage.GetRoomTypes(ctx, []string{hotelID})
+	if err != nil {
+		return nil, fmt.Errorf("get room types from storage: %w", err)
+	}
+
+	return rooms, nil
+}
+
+// CreateRoomType adds a room type to a hotel.
+func (m *Manager) CreateRoomType(ctx context.Context, hotelID string, room *RoomType) (*RoomType, error) {
+	room.HotelID = hotelID
+
+	if err := room.validate(); err != nil {
+		return nil, err
+	}
+
+	rooms, err := m.ListRoomTypes(ctx, hotelID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, existing := range rooms {
+		if existing.ID == room.ID {
+			return nil, fmt.Errorf("room %v in hotel %v: %w", room.ID, hotelID, ErrAlreadyExists)
+		}
+	}
+
+	if err := m.saveRoomType(ctx, room); err != nil {
+		return nil, err
+	}
+
+	return room, nil
+}
+
+// UpdateRoomType replaces settings of a room type.
+func (m *Manager) UpdateRoomType(ctx context.Context, hotelID, id string, room *RoomType) (*RoomType, error) {
+	room.HotelID, room.ID = hotelID, id
+
+	if err := room.validate(); err != nil {
+		return nil, err
+	}
+
+	if _, err := m.GetRoomType(ctx, hotelID, id); err != nil {
+		return nil, err
+	}
+
+	if err := m.saveRoomType(ctx, room); err != nil {
+		return nil, err
+	}
+
+	return room, nil
+}
+
+// DeactivateRoomType stops sales of a room type. Its inventory and rates are kept.
+func (m *Manager) DeactivateRoomType(ctx context.Context, hotelID, id string) (*RoomType, error) {
+	room, err := m.GetRoomType(ctx, hotelID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	room.Active = false
+
+	if err := m.saveRoomType(ctx, room); err != nil {
+		return nil, err
+	}
+
+	return room, nil
+}
+
+func (m *Manager) saveRoomType(ctx context.Context, room *RoomType) error {
+	return m.inTransaction(ctx, func(ctx context.Context) error {
+		if err := m.storage.SaveRoomTypes(ctx, []*RoomType{room}); err != nil {
+			return fmt.Errorf("save room type to storage: %w", err)
+		}
+
+		return nil
+	})
+}
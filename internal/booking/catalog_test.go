@@ -0,0 +1,260 @@
+package booking_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/avstrong/booking/internal/booking"
+)
+
+func TestManager_HotelCatalog(t *testing.T) {
+	t.Parallel()
+
+	//nolint:exhaustruct
+	manager, _ := newManager(t, booking.Config{}, newHotel(), startDate())
+	ctx := context.Background()
+
+	//nolint:exhaustruct
+	created, err := manager.CreateHotel(ctx, &booking.Hotel{ID: "astoria", Name: "Astoria", TimeZone: "Europe/Moscow", Active: true})
+	if err != nil {
+		t.Fatalf("create hotel: %v", err)
+	}
+
+	//nolint:exhaustruct
+	hotel := &booking.Hotel{ID: "astoria", Name: "Copy", TimeZone: "UTC"}
+	if _, err := manager.CreateHotel(ctx, hotel); !errors.Is(err, booking.ErrAlreadyExists) {
+		t.Errorf("creation of an existing hotel got %v, expected %v", err, booking.ErrAlreadyExists)
+	}
+
+	hotels, err := manager.ListHotels(ctx)
+	if err != nil {
+		t.Fatalf("list hotels: %v", err)
+	}
+
+	if len(hotels) != 2 {
+		t.Errorf("listed hotels %+v, expected the saved and the created one", hotels)
+	}
+
+	created.Name = "Astoria Grand"
+
+	if _, err := manager.UpdateHotel(ctx, created.ID, created); err != nil {
+		t.Fatalf("update hotel: %v", err)
+	}
+
+	if _, err := manager.DeactivateHotel(ctx, created.ID); err != nil {
+		t.Fatalf("deactivate hotel: %v", err)
+	}
+
+	stored, err := manager.GetHotel(ctx, created.ID)
+	if err != nil {
+		t.Fatalf("get hotel: %v", err)
+	}
+
+	if stored.Name != "Astoria Grand" || stored.Active {
+		t.Errorf("hotel is %q active=%v, expected the updated name and inactive", stored.Name, stored.Active)
+	}
+
+	//nolint:exhaustruct
+	hotel = &booking.Hotel{Name: "Other", TimeZone: "UTC"}
+	if _, err := manager.UpdateHotel(ctx, "other", hotel); !errors.Is(err, booking.ErrRecordNotFound) {
+		t.Errorf("update of a missing hotel got %v, expected %v", err, booking.ErrRecordNotFound)
+	}
+
+	if _, err := manager.GetHotel(ctx, "other"); !errors.Is(err, booking.ErrRecordNotFound) {
+		t.Errorf("get of a missing hotel got %v, expected %v", err, booking.ErrRecordNotFound)
+	}
+}
+
+func TestManager_CreateHotelRejectsInvalidHotel(t *testing.T) {
+	t.Parallel()
+
+	//nolint:exhaustruct
+	tests := []struct {
+		name  string
+		hotel booking.Hotel
+	}{
+		{name: "no id", hotel: booking.Hotel{Name: "Astoria", TimeZone: "UTC"}},
+		{name: "no name", hotel: booking.Hotel{ID: "astoria", TimeZone: "UTC"}},
+		{name: "no time zone", hotel: booking.Hotel{ID: "astoria", Name: "Astoria"}},
+		{name: "unknown time zone", hotel: booking.Hotel{ID: "astoria", Name: "Astoria", TimeZone: "Mars/Olympus"}},
+		{name: "check-in time", hotel: booking.Hotel{ID: "astoria", Name: "Astoria", TimeZone: "UTC", CheckInTime: "2pm"}},
+		{name: "check-out time", hotel: booking.Hotel{ID: "astoria", Name: "Astoria", TimeZone: "UTC", CheckOutTime: "25:00"}},
+		{name: "tax rule", hotel: booking.Hotel{
+			ID: "astoria", Name: "Astoria", TimeZone: "UTC", Taxes: []booking.TaxRule{{Code: "vat", Kind: "flat"}},
+		}},
+	}
+
+	//nolint:exhaustruct
+	manager, _ := newManager(t, booking.Config{}, newHotel(), startDate())
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if _, err := manager.CreateHotel(context.Background(), &tt.hotel); booking.IsInputError(err) == nil {
+				t.Errorf("creation of hotel %+v got %v, expected input error", tt.hotel, err)
+			}
+		})
+	}
+}
+
+func TestManager_RoomTypeCatalog(t *testing.T) {
+	t.Parallel()
+
+	//nolint:exhaustruct
+	manager, _ := newManager(t, booking.Config{}, newHotel(), startDate())
+	ctx := context.Background()
+
+	//nolint:exhaustruct
+	created, err := manager.CreateRoomType(ctx, "reddison", &booking.RoomType{ID: "std", Name: "Standard", MaxOccupancy: 2, Active: true})
+	if err != nil {
+		t.Fatalf("create room type: %v", err)
+	}
+
+	if created.HotelID != "reddison" {
+		t.Errorf("room type belongs to hotel %q, expected the one of the path", created.HotelID)
+	}
+
+	//nolint:exhaustruct
+	room := &booking.RoomType{ID: "std", Name: "Copy", MaxOccupancy: 1}
+	if _, err := manager.CreateRoomType(ctx, "reddison", room); !errors.Is(err, booking.ErrAlreadyExists) {
+		t.Errorf("creation of an existing room type got %v, expected %v", err, booking.ErrAlreadyExists)
+	}
+
+	//nolint:exhaustruct
+	room = &booking.RoomType{ID: "std", Name: "Standard", MaxOccupancy: 2}
+	if _, err := manager.CreateRoomType(ctx, "other", room); !errors.Is(err, booking.ErrRecordNotFound) {
+		t.Errorf("creation of a room type in a missing hotel got %v, expected %v", err, booking.ErrRecordNotFound)
+	}
+
+	created.MaxOccupancy = 3
+
+	if _, err := manager.UpdateRoomType(ctx, "reddison", "std", created); err != nil {
+		t.Fatalf("update room type: %v", err)
+	}
+
+	if _, err := manager.DeactivateRoomType(ctx, "reddison", "std"); err != nil {
+		t.Fatalf("deactivate room type: %v", err)
+	}
+
+	stored, err := manager.GetRoomType(ctx, "reddison", "std")
+	if err != nil {
+		t.Fatalf("get room type: %v", err)
+	}
+
+	if stored.MaxOccupancy != 3 || stored.Active {
+		t.Errorf("room type takes %v guests active=%v, expected the updated occupancy and inactive", stored.MaxOccupancy, stored.Active)
+	}
+
+	rooms, err := manager.ListRoomTypes(ctx, "reddison")
+	if err != nil {
+		t.Fatalf("list room types: %v", err)
+	}
+
+	if len(rooms) != 2 {
+		t.Errorf("listed room types %+v, expected the saved and the created one", rooms)
+	}
+
+	if _, err := manager.GetRoomType(ctx, "reddison", "suite"); !errors.Is(err, booking.ErrRecordNotFound) {
+		t.Errorf("get of a missing room type got %v, expected %v", err, booking.ErrRecordNotFound)
+	}
+}
+
+func TestManager_CreateRoomTypeRejectsInvalidRoom(t *testing.T) {
+	t.Parallel()
+
+	//nolint:exhaustruct
+	tests := []struct {
+		name string
+		room booking.RoomType
+	}{
+		{name: "no id", room: booking.RoomType{Name: "Standard", MaxOccupancy: 2}},
+		{name: "no name", room: booking.RoomType{ID: "std", MaxOccupancy: 2}},
+		{name: "no occupancy", room: booking.RoomType{ID: "std", Name: "Standard"}},
+	}
+
+	//nolint:exhaustruct
+	manager, _ := newManager(t, booking.Config{}, newHotel(), startDate())
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if _, err := manager.CreateRoomType(context.Background(), "reddison", &tt.room); booking.IsInputError(err) == nil {
+				t.Errorf("creation of room type %+v got %v, expected input error", tt.room, err)
+			}
+		})
+	}
+}
+
+func TestManager_CreateOrderChecksCatalog(t *testing.T) {
+	t.Parallel()
+
+	from := startDate()
+
+	tests := []struct {
+		name string
+		// change makes the catalog unfit for booking the "lux" room.
+		change func(t *testing.T, manager *booking.Manager)
+	}{
+		{name: "inactive hotel", change: func(t *testing.T, manager *booking.Manager) {
+			t.Helper()
+
+			if _, err := manager.DeactivateHotel(context.Background(), "reddison"); err != nil {
+				t.Fatalf("deactivate hotel: %v", err)
+			}
+		}},
+		{name: "inactive room", change: func(t *testing.T, manager *booking.Manager) {
+			t.Helper()
+
+			if _, err := manager.DeactivateRoomType(context.Background(), "reddison", "lux"); err != nil {
+				t.Fatalf("deactivate room type: %v", err)
+			}
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			//nolint:exhaustruct
+			manager, db := newManager(t, booking.Config{}, newHotel(), from)
+			tt.change(t, manager)
+
+			//nolint:exhaustruct
+			_, err := manager.CreateOrder(booking.NewContextWithIdempotencyKey(context.Background(), "key"), &booking.BookInput{
+				Payer:  booking.Payer{Email: "guest@mail.ru"},
+				Places: []booking.Place{luxPlace(from, 1)},
+			})
+			if booking.IsInputError(err) == nil {
+				t.Errorf("create order got %v, expected input error", err)
+			}
+
+			if got := quotas(t, db, from); got[0] != quota {
+				t.Errorf("quotas are %v after a refused order, expected the first night untouched", got)
+			}
+		})
+	}
+}
+
+func TestManager_CreateOrderRejectsUnknownTimeZone(t *testing.T) {
+	t.Parallel()
+
+	from := startDate()
+	hotel := newHotel()
+	// The time zone is not checked when the hotel is saved to storage directly, e.g. by a migration.
+	hotel.TimeZone = "Mars/Olympus"
+
+	//nolint:exhaustruct
+	manager, _ := newManager(t, booking.Config{}, hotel, from)
+
+	//nolint:exhaustruct
+	order, err := manager.CreateOrder(booking.NewContextWithIdempotencyKey(context.Background(), "key"), &booking.BookInput{
+		Payer:  booking.Payer{Email: "guest@mail.ru"},
+		Places: []booking.Place{luxPlace(from, 1)},
+	})
+	if err == nil {
+		t.Errorf("order %+v is booked in a hotel with an unknown time zone", order)
+	}
+}
@@ -1,6 +1,10 @@
 package booking
 
-import "time"
+import (
+	"time"
+
+	"github.com/avstrong/booking/internal/money"
+)
 
 type RoomAvailability struct {
 	HotelID string    `json:"hotel_id"`
@@ -9,6 +13,28 @@ type RoomAvailability struct {
 	Quota   int       `json:"quota"`
 }
 
+// RoomRate is the price of a room for one night. Price is charged for double occupancy. One guest pays
+// SinglePrice when it is set, and every guest over two adds ExtraBedPrice when it is set.
+type RoomRate struct {
+	HotelID       string       `json:"hotel_id"`
+	RoomID        string       `json:"room_id"`
+	Date          time.Time    `json:"date"`
+	Price         money.Money  `json:"price"`
+	SinglePrice   *money.Money `json:"single_price,omitempty"`
+	ExtraBedPrice *money.Money `json:"extra_bed_price,omitempty"`
+}
+
+// QuotaChange is a relative change of a room quota on a date. When Expected is set, the change is only valid
+// for that quota, so it can be calculated from an absolute one.
+type QuotaChange struct {
+	HotelID  string
+	RoomID   string
+	Date     time.Time
+	Delta    int
+	Expected *int
+}
+
+// GetAvailabilityInput requests availability for nights in [From, To).
 type GetAvailabilityInput struct {
 	HotelID string
 	RoomID  string
@@ -16,18 +42,81 @@ type GetAvailabilityInput struct {
 	To      time.Time
 }
 
+type EventType string
+
+const (
+	EventOrderCreated       EventType = "order_created"
+	EventOrderStatusChanged EventType = "order_status_changed"
+	EventOrderUpdated       EventType = "order_updated"
+	EventInventoryChanged   EventType = "inventory_changed"
+)
+
 type Event struct {
-	ID        int
-	OrderID   int
-	CreatedAt time.Time
+	ID         int
+	OrderID    int
+	Type       EventType
+	FromStatus OrderStatus
+	ToStatus   OrderStatus
+	CreatedAt  time.Time
+	// Inventory and QuotaChanges are set for inventory changes, which have no order.
+	Inventory    *InventoryInput
+	QuotaChanges []QuotaChange
+}
+
+// Occupancy is who stays in a room. Children take a bed as adults do.
+type Occupancy struct {
+	Adults   int `json:"adults"`
+	Children int `json:"children"`
+}
+
+// Guests returns how many people stay in the room.
+func (o Occupancy) Guests() int {
+	return o.Adults + o.Children
+}
+
+// orDefault returns the occupancy of a place, which is one adult when none is given.
+func (o Occupancy) orDefault() Occupancy {
+	if o.Guests() == 0 {
+		return Occupancy{Adults: 1, Children: 0}
+	}
+
+	return o
+}
+
+// Guest is a person staying in a room, as written on the registration card.
+type Guest struct {
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
 }
 
+// Place is a room booked for nights in the half-open range [From, To): a guest arrives on From
+// and leaves on To, so the night of To is not consumed. From and To are calendar dates in the
+// hotel's time zone; CheckIn and CheckOut are the exact moments derived from the hotel's settings.
+// One adult is assumed when the occupancy is not given. Guests may name some or all of the people staying.
 type Place struct {
-	HotelID string    `json:"hotel_id"`
-	RoomID  string    `json:"room_id"`
-	From    time.Time `json:"from"`
-	To      time.Time `json:"to"`
-	Price   float64
+	HotelID   string      `json:"hotel_id"`
+	RoomID    string      `json:"room_id"`
+	From      time.Time   `json:"from"`
+	To        time.Time   `json:"to"`
+	Occupancy Occupancy   `json:"occupancy"`
+	Guests    []Guest     `json:"guests,omitempty"`
+	CheckIn   time.Time   `json:"check_in"`
+	CheckOut  time.Time   `json:"check_out"`
+	Price     money.Money `json:"price"`
+}
+
+// Nights returns the number of nights between the arrival and departure dates.
+func (p *Place) Nights() int {
+	return int(p.To.Sub(p.From).Round(24*time.Hour) / (24 * time.Hour)) //nolint:gomnd
+}
+
+// PromoRedemption is a usage of a promo code by an order. Usages count against limits of the code
+// until the order is cancelled.
+type PromoRedemption struct {
+	Code    string
+	OrderID int
+	// PayerEmail is lower-cased, so limits per payer do not depend on the case of the email.
+	PayerEmail string
 }
 
 type Payer struct {
@@ -35,15 +124,66 @@ type Payer struct {
 }
 
 type BookInput struct {
-	Payer           Payer   `json:"payer"`
-	Places          []Place `json:"places"`
-	BoostStrategies []BoostStrategy
+	Payer  Payer   `json:"payer"`
+	Places []Place `json:"places"`
+	// Hold makes a temporary reservation which has to be confirmed before it expires. Other orders are created
+	// pending and are confirmed once the booking is settled.
+	Hold bool `json:"hold"`
+	// DisplayCurrency asks to convert the order price into another currency.
+	DisplayCurrency money.Currency `json:"display_currency,omitempty"`
+	// PromoCodes are codes of the promo catalog the client wants to use.
+	PromoCodes []string `json:"promo_codes,omitempty"`
+}
+
+type LineItemType string
+
+const (
+	LineItemNight    LineItemType = "night"
+	LineItemDiscount LineItemType = "discount"
+	// LineItemDiscountCap gives back the part of discounts over the cap of their strategies.
+	LineItemDiscountCap LineItemType = "discount_cap"
+	// LineItemPerk is a free service of a boost strategy, e.g. a late checkout for a loyalty tier.
+	LineItemPerk LineItemType = "perk"
+	LineItemTax  LineItemType = "tax"
+	LineItemFee  LineItemType = "fee"
+)
+
+// LineItem is one component of the order price. Night items carry the base price of a room for a date,
+// discount items carry a negative amount and the boost strategy which produced it, tax and fee items carry
+// the code of the hotel's tax rule. Included items are already part of the price and are not added to it.
+type LineItem struct {
+	Type         LineItemType `json:"type"`
+	HotelID      string       `json:"hotel_id,omitempty"`
+	RoomID       string       `json:"room_id,omitempty"`
+	Date         *time.Time   `json:"date,omitempty"`
+	StrategyType string       `json:"strategy_type,omitempty"`
+	StrategyCode string       `json:"strategy_code,omitempty"`
+	Perk         string       `json:"perk,omitempty"`
+	TaxCode      string       `json:"tax_code,omitempty"`
+	Included     bool         `json:"included,omitempty"`
+	Amount       money.Money  `json:"amount"`
 }
 
 type Order struct {
-	ID        int       `json:"id"`
-	Payer     Payer     `json:"payer"`
-	Places    []Place   `json:"places"`
-	CreatedAt time.Time `json:"created_at"`
-	Price     float64   `json:"price"`
+	ID          int         `json:"id"`
+	Status      OrderStatus `json:"status"`
+	Payer       Payer       `json:"payer"`
+	Places      []Place     `json:"places"`
+	CreatedAt   time.Time   `json:"created_at"`
+	UpdatedAt   time.Time   `json:"updated_at"`
+	CancelledAt *time.Time  `json:"cancelled_at,omitempty"`
+	// HoldExpiresAt is set for held orders only.
+	HoldExpiresAt *time.Time `json:"hold_expires_at,omitempty"`
+	// LineItems explain the price: the order price is their sum.
+	LineItems []LineItem  `json:"line_items"`
+	Price     money.Money `json:"price"`
+	// RejectedBoosts are strategies which were not applied because of their stacking rules, and promo codes
+	// which no longer apply to the places of a changed order.
+	RejectedBoosts []RejectedBoost `json:"rejected_boosts,omitempty"`
+	// PromoCodes are the promo codes the order was booked with.
+	PromoCodes []string `json:"promo_codes,omitempty"`
+	// Display is the price in the display currency requested by the client.
+	Display *DisplayPrice `json:"display,omitempty"`
+	// Version is increased on every change. Storage refuses to save an order over a newer version.
+	Version int `json:"-"`
 }
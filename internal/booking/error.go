@@ -11,6 +11,19 @@ var (
 	ErrNextID         = errors.New("get next id from generator")
 	ErrLogic          = errors.New("logic error")
 	ErrRecordNotFound = errors.New("record not found")
+	ErrConflict       = errors.New("concurrent update conflict")
+	ErrAlreadyExists  = errors.New("record already exists")
+
+	ErrIdempotencyKeyInProgress = errors.New("request with the same idempotency key is in progress")
+	ErrIdempotencyKeyMismatch   = errors.New("idempotency key has already been used with a different request")
+
+	ErrOrderNotModifiable = errors.New("order can not be modified in its current status")
+	ErrHoldExpired        = errors.New("order hold expired")
+	ErrHoldActive         = errors.New("order hold has not expired yet")
+
+	ErrInvalidTaxRule       = errors.New("invalid tax rule")
+	ErrExchangeRateNotFound = errors.New("exchange rate not found")
+	ErrTooManyBoosts        = errors.New("too many boost strategies")
 )
 
 type AvailabilityError struct {
@@ -40,6 +53,10 @@ func (e *AvailabilityError) AddUnavailableRoom(hotelID, roomID string, dates []t
 	e.errors = append(e.errors, fmt.Sprintf("room '%v' is unavalable in hotel '%v' on following dates %+v", roomID, hotelID, dates))
 }
 
+func (e *AvailabilityError) AddUnpricedRoom(hotelID, roomID string, dates []time.Time) {
+	e.errors = append(e.errors, fmt.Sprintf("room '%v' has no price in hotel '%v' on following dates %+v", roomID, hotelID, dates))
+}
+
 func (e *AvailabilityError) Error() string {
 	return fmt.Sprintf("%+v", e.errors)
 }
@@ -56,7 +73,7 @@ type InputError struct {
 	fields map[string][]string
 }
 
-func newInputError() *InputError {
+func NewInputError() *InputError {
 	return &InputError{
 		fields: make(map[string][]string),
 	}
@@ -76,11 +93,11 @@ func IsInputError(err error) *InputError {
 	return nil
 }
 
-func (ie *InputError) fieldsCount() int {
+func (ie *InputError) FieldsCount() int {
 	return len(ie.fields)
 }
 
-func (ie *InputError) addError(field, msg string) {
+func (ie *InputError) AddError(field, msg string) {
 	ie.fields[field] = append(ie.fields[field], msg)
 }
 
@@ -91,3 +108,118 @@ func (ie *InputError) Error() string {
 func (ie *InputError) Fields() map[string][]string {
 	return ie.fields
 }
+
+type TransitionError struct {
+	From OrderStatus
+	To   OrderStatus
+}
+
+func IsTransitionError(err error) *TransitionError {
+	if err == nil {
+		return nil
+	}
+
+	var transitionError *TransitionError
+
+	if errors.As(err, &transitionError) {
+		return transitionError
+	}
+
+	return nil
+}
+
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("order can not be moved from '%v' to '%v'", e.From, e.To)
+}
+
+// RestrictionError tells that stay restrictions forbid some places even though their rooms are available.
+type RestrictionError struct {
+	Violations []RestrictionViolation
+}
+
+func NewRestrictionError() *RestrictionError {
+	//nolint:exhaustruct
+	return &RestrictionError{}
+}
+
+func IsRestrictionError(err error) *RestrictionError {
+	if err == nil {
+		return nil
+	}
+
+	var restrictionError *RestrictionError
+
+	if errors.As(err, &restrictionError) {
+		return restrictionError
+	}
+
+	return nil
+}
+
+func (e *RestrictionError) Error() string {
+	messages := make([]string, 0, len(e.Violations))
+	for _, violation := range e.Violations {
+		messages = append(messages, violation.String())
+	}
+
+	return fmt.Sprintf("%+v", messages)
+}
+
+// OverbookingError tells that an inventory change would take rooms of existing orders off sale.
+type OverbookingError struct {
+	HotelID string
+	RoomID  string
+	Dates   []time.Time
+}
+
+func IsOverbookingError(err error) *OverbookingError {
+	if err == nil {
+		return nil
+	}
+
+	var overbookingError *OverbookingError
+
+	if errors.As(err, &overbookingError) {
+		return overbookingError
+	}
+
+	return nil
+}
+
+func (e *OverbookingError) Error() string {
+	return fmt.Sprintf(
+		"room '%v' in hotel '%v' does not have enough unsold quota on following dates %+v",
+		e.RoomID,
+		e.HotelID,
+		e.Dates,
+	)
+}
+
+// PromoLimitError tells that a promo code can not be used once more.
+type PromoLimitError struct {
+	Code string
+	// PerPayer is set when the payer has used up the code, while others still can use it.
+	PerPayer bool
+}
+
+func IsPromoLimitError(err error) *PromoLimitError {
+	if err == nil {
+		return nil
+	}
+
+	var promoLimitError *PromoLimitError
+
+	if errors.As(err, &promoLimitError) {
+		return promoLimitError
+	}
+
+	return nil
+}
+
+func (e *PromoLimitError) Error() string {
+	if e.PerPayer {
+		return fmt.Sprintf("promo code '%v' has already been used by the payer", e.Code)
+	}
+
+	return fmt.Sprintf("promo code '%v' has been used up", e.Code)
+}
@@ -0,0 +1,73 @@
+package booking
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/avstrong/booking/internal/money"
+)
+
+// ExchangeRate is the price of one major unit of the From currency in major units of the To currency
+// on a date.
+type ExchangeRate struct {
+	From money.Currency `json:"from"`
+	To   money.Currency `json:"to"`
+	Date time.Time      `json:"date"`
+	Rate float64        `json:"rate"`
+}
+
+type exchangeRates interface {
+	// ExchangeRate returns the latest rate known on the date. It fails with ErrExchangeRateNotFound
+	// when there is none.
+	ExchangeRate(ctx context.Context, from, to money.Currency, date time.Time) (*ExchangeRate, error)
+}
+
+// DisplayPrice is the order price converted to the currency the client asked for. The order is still
+// settled in the currency of its price; the rate is kept to show how the amount was reached.
+type DisplayPrice struct {
+	Price money.Money   `json:"price"`
+	Rate  *ExchangeRate `json:"rate"`
+}
+
+func validateDisplayCurrency(inputErr *InputError, currency money.Currency) {
+	if currency != "" && !currency.Valid() {
+		inputErr.AddError("display_currency", fmt.Sprintf("unknown currency '%v'", currency))
+	}
+}
+
+// convertPrice sets the display price of the order in the currency by the rate of the day.
+func (m *Manager) convertPrice(ctx context.Context, order *Order, currency money.Currency) error {
+	if currency == "" {
+		order.Display = nil
+
+		return nil
+	}
+
+	inputErr := NewInputError()
+
+	if m.exchangeRates == nil {
+		inputErr.AddError("display_currency", "display currencies are not supported")
+
+		return inputErr
+	}
+
+	rate, err := m.exchangeRates.ExchangeRate(ctx, order.Price.Currency, currency, time.Now().UTC())
+	if errors.Is(err, ErrExchangeRateNotFound) {
+		inputErr.AddError("display_currency", fmt.Sprintf("no exchange rate from %v to %v", order.Price.Currency, currency))
+
+		return inputErr
+	}
+
+	if err != nil {
+		return fmt.Errorf("get exchange rate from %v to %v: %w", order.Price.Currency, currency, err)
+	}
+
+	order.Display = &DisplayPrice{
+		Price: order.Price.Convert(currency, rate.Rate),
+		Rate:  rate,
+	}
+
+	return nil
+}
@@ -0,0 +1,142 @@
+package booking_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/avstrong/booking/internal/booking"
+)
+
+// holdOrder makes a temporary reservation of the place.
+func holdOrder(t *testing.T, manager *booking.Manager, key string, place booking.Place) *booking.Order {
+	t.Helper()
+
+	//nolint:exhaustruct
+	order, err := manager.CreateOrder(booking.NewContextWithIdempotencyKey(context.Background(), key), &booking.BookInput{
+		Payer:  booking.Payer{Email: "guest@mail.ru"},
+		Places: []booking.Place{place},
+		Hold:   true,
+	})
+	if err != nil {
+		t.Fatalf("hold order: %v", err)
+	}
+
+	if order.Status != booking.OrderStatusHeld || order.HoldExpiresAt == nil {
+		t.Fatalf("held order is %v until %v, expected %v with expiry", order.Status, order.HoldExpiresAt, booking.OrderStatusHeld)
+	}
+
+	return order
+}
+
+func TestManager_LiveHold(t *testing.T) {
+	t.Parallel()
+
+	from := startDate()
+	//nolint:exhaustruct
+	manager, _ := newManager(t, booking.Config{HoldTTL: time.Hour}, newHotel(), from)
+
+	order := holdOrder(t, manager, "hold", luxPlace(from, 1))
+
+	_, err := manager.ChangeOrderStatus(context.Background(), order.ID, booking.OrderStatusExpired)
+	if !errors.Is(err, booking.ErrHoldActive) {
+		t.Errorf("expiry of a live hold got %v, expected %v", err, booking.ErrHoldActive)
+	}
+
+	_, err = manager.UpdateOrder(booking.NewContextWithIdempotencyKey(context.Background(), "update"), order.ID,
+		&booking.UpdateOrderInput{Places: []booking.Place{luxPlace(from, 2)}})
+	if err != nil {
+		t.Errorf("update live hold: %v", err)
+	}
+
+	confirmed, err := manager.ConfirmOrder(context.Background(), order.ID)
+	if err != nil {
+		t.Fatalf("confirm live hold: %v", err)
+	}
+
+	if confirmed.Status != booking.OrderStatusConfirmed || confirmed.HoldExpiresAt != nil {
+		t.Errorf("confirmed order is %v until %v, expected %v without expiry",
+			confirmed.Status, confirmed.HoldExpiresAt, booking.OrderStatusConfirmed)
+	}
+}
+
+func TestManager_ExpiredHold(t *testing.T) {
+	t.Parallel()
+
+	from := startDate()
+	//nolint:exhaustruct
+	manager, _ := newManager(t, booking.Config{HoldTTL: 10 * time.Millisecond}, newHotel(), from)
+
+	order := holdOrder(t, manager, "hold", luxPlace(from, 1))
+
+	time.Sleep(20 * time.Millisecond)
+
+	if _, err := manager.ConfirmOrder(context.Background(), order.ID); !errors.Is(err, booking.ErrHoldExpired) {
+		t.Errorf("confirmation of an expired hold got %v, expected %v", err, booking.ErrHoldExpired)
+	}
+
+	_, err := manager.UpdateOrder(booking.NewContextWithIdempotencyKey(context.Background(), "update"), order.ID,
+		&booking.UpdateOrderInput{Places: []booking.Place{luxPlace(from, 2)}})
+	if !errors.Is(err, booking.ErrHoldExpired) {
+		t.Errorf("update of an expired hold got %v, expected %v", err, booking.ErrHoldExpired)
+	}
+
+	stored, err := manager.GetOrder(context.Background(), order.ID)
+	if err != nil {
+		t.Fatalf("get order: %v", err)
+	}
+
+	if stored.Status != booking.OrderStatusHeld {
+		t.Errorf("order is %v, expected it held until released", stored.Status)
+	}
+}
+
+func TestManager_ReleaseExpiredHolds(t *testing.T) {
+	t.Parallel()
+
+	from := startDate()
+	//nolint:exhaustruct
+	manager, db := newManager(t, booking.Config{HoldTTL: 10 * time.Millisecond}, newHotel(), from)
+
+	held := holdOrder(t, manager, "hold", luxPlace(from, 1))
+	pending := createOrder(t, manager, "create", luxPlace(from, 1))
+
+	time.Sleep(20 * time.Millisecond)
+
+	released, err := manager.ReleaseExpiredHolds(context.Background())
+	if err != nil {
+		t.Fatalf("release expired holds: %v", err)
+	}
+
+	if released != 1 {
+		t.Errorf("released %v holds, expected 1", released)
+	}
+
+	// Quota of the held nights is back, the pending order keeps its rooms.
+	want := []int{quota - 1, quota, quota}
+	if got := quotas(t, db, from); !slices.Equal(got, want) {
+		t.Errorf("quotas are %v after release, expected %v", got, want)
+	}
+
+	for id, status := range map[int]booking.OrderStatus{held.ID: booking.OrderStatusExpired, pending.ID: booking.OrderStatusPending} {
+		order, err := manager.GetOrder(context.Background(), id)
+		if err != nil {
+			t.Fatalf("get order: %v", err)
+		}
+
+		if order.Status != status {
+			t.Errorf("order %v is %v, expected %v", id, order.Status, status)
+		}
+	}
+
+	released, err = manager.ReleaseExpiredHolds(context.Background())
+	if err != nil {
+		t.Fatalf("release expired holds again: %v", err)
+	}
+
+	if released != 0 {
+		t.Errorf("released %v holds again, expected 0", released)
+	}
+}
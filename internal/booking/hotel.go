@@ -0,0 +1,166 @@
+package booking
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const (
+	defaultCheckInTime  = "14:00"
+	defaultCheckOutTime = "12:00"
+	clockLayout         = "15:04"
+)
+
+// Hotel is a property of the catalog. It keeps the local calendar of the property: nights, availability dates
+// and the "not in the past" check are calculated in the hotel's time zone. Inactive hotels can not be booked.
+type Hotel struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Address string `json:"address"`
+	// TimeZone is an IANA time zone name, e.g. "Asia/Vladivostok".
+	TimeZone string `json:"time_zone"`
+	// CheckInTime and CheckOutTime are local times in 15:04 format.
+	CheckInTime  string `json:"check_in_time"`
+	CheckOutTime string `json:"check_out_time"`
+	// Taxes are taxes and fees charged for stays in the hotel.
+	Taxes     []TaxRule `json:"taxes"`
+	Amenities []string  `json:"amenities"`
+	Active    bool      `json:"active"`
+
+	location *time.Location
+	checkIn  clock
+	checkOut clock
+	rooms    map[string]*RoomType
+}
+
+// RoomType is a kind of rooms of a hotel which is sold under one room id, e.g. "lux".
+type RoomType struct {
+	HotelID string `json:"hotel_id"`
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	// MaxOccupancy is how many guests can stay in one room.
+	MaxOccupancy int      `json:"max_occupancy"`
+	Amenities    []string `json:"amenities"`
+	Active       bool     `json:"active"`
+}
+
+// clock is a local time of day. It is kept as hour and minute rather than an offset from midnight,
+// because a day is not 24 hours long when daylight saving time changes.
+type clock struct {
+	hour   int
+	minute int
+}
+
+func parseClock(value string) (clock, error) {
+	t, err := time.Parse(clockLayout, value)
+	if err != nil {
+		return clock{}, fmt.Errorf("parse time of day %q: %w", value, err)
+	}
+
+	return clock{hour: t.Hour(), minute: t.Minute()}, nil
+}
+
+func (h *Hotel) init() error {
+	location, err := time.LoadLocation(h.TimeZone)
+	if err != nil {
+		return fmt.Errorf("load time zone of hotel %v: %w", h.ID, err)
+	}
+
+	if h.CheckInTime == "" {
+		h.CheckInTime = defaultCheckInTime
+	}
+
+	if h.CheckOutTime == "" {
+		h.CheckOutTime = defaultCheckOutTime
+	}
+
+	checkIn, err := parseClock(h.CheckInTime)
+	if err != nil {
+		return fmt.Errorf("check-in time of hotel %v: %w", h.ID, err)
+	}
+
+	checkOut, err := parseClock(h.CheckOutTime)
+	if err != nil {
+		return fmt.Errorf("check-out time of hotel %v: %w", h.ID, err)
+	}
+
+	for idx := range h.Taxes {
+		if err := h.Taxes[idx].validate(); err != nil {
+			return fmt.Errorf("taxes of hotel %v: %w", h.ID, err)
+		}
+	}
+
+	h.location = location
+	h.checkIn = checkIn
+	h.checkOut = checkOut
+
+	return nil
+}
+
+// date returns the hotel's local calendar date of t. Dates are represented as midnight UTC,
+// the same way availability dates are stored.
+func (h *Hotel) date(t time.Time) time.Time {
+	year, month, day := t.In(h.location).Date()
+
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func (h *Hotel) today() time.Time {
+	return h.date(time.Now())
+}
+
+// at returns the moment of the local time of day on the calendar date.
+func (h *Hotel) at(date time.Time, c clock) time.Time {
+	year, month, day := date.Date()
+
+	return time.Date(year, month, day, c.hour, c.minute, 0, 0, h.location)
+}
+
+// room returns the room type of the hotel, or nil when the hotel has no such room.
+func (h *Hotel) room(id string) *RoomType {
+	return h.rooms[id]
+}
+
+// getHotels returns hotels of the places with their room types. Hotels missing from the catalog are not
+// in the result, validation reports them.
+func (m *Manager) getHotels(ctx context.Context, places []Place) (map[string]*Hotel, error) {
+	ids := make([]string, 0, len(places))
+	seen := make(map[string]bool, len(places))
+
+	for _, place := range places {
+		if !seen[place.HotelID] {
+			seen[place.HotelID] = true
+			ids = append(ids, place.HotelID)
+		}
+	}
+
+	found, err := m.storage.GetHotels(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("get hotels from storage: %w", err)
+	}
+
+	rooms, err := m.storage.GetRoomTypes(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("get room types from storage: %w", err)
+	}
+
+	hotels := make(map[string]*Hotel, len(found))
+
+	for _, hotel := range found {
+		if err := hotel.init(); err != nil {
+			return nil, err
+		}
+
+		hotel.rooms = make(map[string]*RoomType)
+		hotels[hotel.ID] = hotel
+	}
+
+	for _, room := range rooms {
+		if hotel, ok := hotels[room.HotelID]; ok {
+			hotel.rooms[room.ID] = room
+		}
+	}
+
+	return hotels, nil
+}
@@ -0,0 +1,31 @@
+package booking
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHotel_AtKeepsLocalTimeOnDSTChange(t *testing.T) {
+	t.Parallel()
+
+	//nolint:exhaustruct
+	hotel := &Hotel{ID: "berlin", TimeZone: "Europe/Berlin", CheckInTime: "14:00", CheckOutTime: "12:00"}
+	if err := hotel.init(); err != nil {
+		t.Fatalf("init hotel: %v", err)
+	}
+
+	// Clocks go forward at 02:00 on 31 March 2030 and back at 03:00 on 27 October 2030 in Berlin.
+	for _, date := range []time.Time{
+		time.Date(2030, 3, 31, 0, 0, 0, 0, time.UTC),
+		time.Date(2030, 10, 27, 0, 0, 0, 0, time.UTC),
+	} {
+		checkIn := hotel.at(date, hotel.checkIn).In(hotel.location)
+		if checkIn.Hour() != 14 || checkIn.Minute() != 0 {
+			t.Errorf("check-in on %v is at %v, expected 14:00 local time", date.Format(time.DateOnly), checkIn.Format(clockLayout))
+		}
+
+		if year, month, day := checkIn.Date(); year != date.Year() || month != date.Month() || day != date.Day() {
+			t.Errorf("check-in on %v falls on %v", date.Format(time.DateOnly), checkIn.Format(time.DateOnly))
+		}
+	}
+}
@@ -0,0 +1,209 @@
+package booking
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/avstrong/booking/internal/money"
+)
+
+type IdempotencyStatus string
+
+const (
+	IdempotencyInProgress IdempotencyStatus = "in_progress"
+	IdempotencyCompleted  IdempotencyStatus = "completed"
+	IdempotencyFailed     IdempotencyStatus = "failed"
+)
+
+const scopeCreateOrder = "create_order"
+
+func scopeUpdateOrder(id int) string {
+	return fmt.Sprintf("update_order:%d", id)
+}
+
+// IdempotencyRecord tracks processing of a request with an Idempotency-Key.
+// Scope is the operation the key was first used for.
+type IdempotencyRecord struct {
+	Key    string
+	Scope  string
+	Status IdempotencyStatus
+	// Fingerprint is a hash of the canonicalized request the key was used with.
+	Fingerprint string
+	// Order is the result of the completed request which is replayed to retries.
+	Order     *Order
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	// ExpiresAt is the moment after which the key is forgotten and may be purged.
+	ExpiresAt time.Time
+}
+
+type canonicalPlace struct {
+	HotelID   string    `json:"hotel_id"`
+	RoomID    string    `json:"room_id"`
+	From      time.Time `json:"from"`
+	To        time.Time `json:"to"`
+	Occupancy Occupancy `json:"occupancy"`
+	Guests    []Guest   `json:"guests"`
+}
+
+// canonicalPlaces drops fields filled by the service, brings times to UTC, fills in the default occupancy
+// and sorts places, so equal requests have equal fingerprints regardless of formatting.
+func canonicalPlaces(places []Place) []canonicalPlace {
+	result := make([]canonicalPlace, 0, len(places))
+
+	for _, place := range places {
+		result = append(result, canonicalPlace{
+			HotelID:   place.HotelID,
+			RoomID:    place.RoomID,
+			From:      place.From.UTC(),
+			To:        place.To.UTC(),
+			Occupancy: place.Occupancy.orDefault(),
+			Guests:    place.Guests,
+		})
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		a, b := result[i], result[j]
+
+		switch {
+		case a.HotelID != b.HotelID:
+			return a.HotelID < b.HotelID
+		case a.RoomID != b.RoomID:
+			return a.RoomID < b.RoomID
+		case !a.From.Equal(b.From):
+			return a.From.Before(b.From)
+		default:
+			return a.To.Before(b.To)
+		}
+	})
+
+	return result
+}
+
+func fingerprint(canonical any) (string, error) {
+	raw, err := json.Marshal(canonical)
+	if err != nil {
+		return "", fmt.Errorf("marshal canonical request: %w", err)
+	}
+
+	sum := sha256.Sum256(raw)
+
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func (b *BookInput) fingerprint() (string, error) {
+	return fingerprint(struct {
+		Payer  string           `json:"payer"`
+		Places []canonicalPlace `json:"places"`
+		Hold   bool             `json:"hold"`
+		// DisplayCurrency changes the response, so it is a part of the request.
+		DisplayCurrency money.Currency `json:"display_currency"`
+		PromoCodes      []string       `json:"promo_codes"`
+	}{
+		Payer:           normalizeEmail(b.Payer.Email),
+		Places:          canonicalPlaces(b.Places),
+		Hold:            b.Hold,
+		DisplayCurrency: b.DisplayCurrency,
+		PromoCodes:      b.PromoCodes,
+	})
+}
+
+func (u *UpdateOrderInput) fingerprint() (string, error) {
+	return fingerprint(struct {
+		Places []canonicalPlace `json:"places"`
+	}{
+		Places: canonicalPlaces(u.Places),
+	})
+}
+
+// idempotent runs fn at most once per idempotency key from ctx. A retry of a completed request gets
+// the original result, a duplicate of a request which is still being processed gets ErrIdempotencyKeyInProgress
+// and a request with a different payload gets ErrIdempotencyKeyMismatch. A key of a failed request may be used again.
+// fn must complete the key in its transaction.
+func (m *Manager) idempotent(
+	ctx context.Context,
+	scope string,
+	requestFingerprint string,
+	fn func(ctx context.Context) (*Order, error),
+) (_ *Order, err error) {
+	key, ok := IdempotencyKeyFromContext(ctx)
+	if !ok || key == "" {
+		return nil, ErrIdempotencyKey
+	}
+
+	now := time.Now().UTC()
+
+	record, reserved, err := m.storage.ReserveIdempotencyKey(ctx, &IdempotencyRecord{
+		Key:         key,
+		Scope:       scope,
+		Status:      IdempotencyInProgress,
+		Fingerprint: requestFingerprint,
+		Order:       nil,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+		ExpiresAt:   now.Add(m.conf.IdempotencyKeyTTL),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("reserve idempotency key: %w", err)
+	}
+
+	if record.Scope != scope {
+		inputErr := NewInputError()
+		inputErr.AddError("idempotency_key", "key has already been used for another request")
+
+		return nil, inputErr
+	}
+
+	if record.Fingerprint != requestFingerprint {
+		return nil, ErrIdempotencyKeyMismatch
+	}
+
+	if !reserved {
+		if record.Status == IdempotencyCompleted {
+			return record.Order, nil
+		}
+
+		return nil, ErrIdempotencyKeyInProgress
+	}
+
+	defer func() {
+		if err == nil {
+			return
+		}
+
+		if failErr := m.storage.FailIdempotencyKey(ctx, key); failErr != nil {
+			m.l.LogErrorf("Could not release idempotency key %v: %v", key, failErr.Error())
+		}
+	}()
+
+	return fn(ctx)
+}
+
+// completeIdempotencyKey binds the result to the idempotency key from ctx within the transaction.
+func (m *Manager) completeIdempotencyKey(ctx context.Context, order *Order) error {
+	key, ok := IdempotencyKeyFromContext(ctx)
+	if !ok || key == "" {
+		return ErrIdempotencyKey
+	}
+
+	if err := m.storage.CompleteIdempotencyKey(ctx, key, order); err != nil {
+		return fmt.Errorf("complete idempotency key in storage: %w", err)
+	}
+
+	return nil
+}
+
+// PurgeExpiredIdempotencyKeys removes idempotency keys whose TTL is over and returns their number.
+func (m *Manager) PurgeExpiredIdempotencyKeys(ctx context.Context) (int, error) {
+	purged, err := m.storage.PurgeIdempotencyKeys(ctx, time.Now().UTC())
+	if err != nil {
+		return 0, fmt.Errorf("purge idempotency keys in storage: %w", err)
+	}
+
+	return purged, nil
+}
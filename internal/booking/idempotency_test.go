@@ -0,0 +1,199 @@
+package booking_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/avstrong/booking/internal/booking"
+	"github.com/avstrong/booking/internal/boost"
+)
+
+func TestManager_CreateOrderIdempotency(t *testing.T) {
+	t.Parallel()
+
+	from := startDate()
+	//nolint:exhaustruct
+	manager, db := newManager(t, booking.Config{IdempotencyKeyTTL: time.Hour}, newHotel(), from)
+	ctx := booking.NewContextWithIdempotencyKey(context.Background(), "key")
+
+	first := createOrder(t, manager, "key", luxPlace(from, 2))
+
+	// Emails are compared case-insensitively, so the retry is the same request.
+	//nolint:exhaustruct
+	retried, err := manager.CreateOrder(ctx, &booking.BookInput{
+		Payer:  booking.Payer{Email: "Guest@Mail.ru"},
+		Places: []booking.Place{luxPlace(from, 2)},
+	})
+	if err != nil {
+		t.Fatalf("retry order: %v", err)
+	}
+
+	if retried.ID != first.ID {
+		t.Errorf("retry created order %v, expected replay of order %v", retried.ID, first.ID)
+	}
+
+	if got, want := quotas(t, db, from), []int{quota - 1, quota - 1, quota}; !slices.Equal(got, want) {
+		t.Errorf("quotas are %v after a retry, expected %v", got, want)
+	}
+
+	//nolint:exhaustruct
+	_, err = manager.CreateOrder(ctx, &booking.BookInput{
+		Payer:  booking.Payer{Email: "guest@mail.ru"},
+		Places: []booking.Place{luxPlace(from, 3)},
+	})
+	if !errors.Is(err, booking.ErrIdempotencyKeyMismatch) {
+		t.Errorf("request with another body got %v, expected %v", err, booking.ErrIdempotencyKeyMismatch)
+	}
+}
+
+func TestManager_CreateOrderReusesKeyOfFailedRequest(t *testing.T) {
+	t.Parallel()
+
+	from := startDate()
+	//nolint:exhaustruct
+	manager, _ := newManager(t, booking.Config{IdempotencyKeyTTL: time.Hour}, newHotel(), from)
+	ctx := booking.NewContextWithIdempotencyKey(context.Background(), "key")
+
+	// Nights after the third one are not on sale.
+	//nolint:exhaustruct
+	_, err := manager.CreateOrder(ctx, &booking.BookInput{
+		Payer:  booking.Payer{Email: "guest@mail.ru"},
+		Places: []booking.Place{luxPlace(from, nights+1)},
+	})
+	if booking.IsAvailabilityError(err) == nil {
+		t.Fatalf("create order got %v, expected availability error", err)
+	}
+
+	createOrder(t, manager, "key", luxPlace(from, 1))
+}
+
+func TestManager_UpdateOrderRejectsKeyOfAnotherRequest(t *testing.T) {
+	t.Parallel()
+
+	from := startDate()
+	//nolint:exhaustruct
+	manager, _ := newManager(t, booking.Config{IdempotencyKeyTTL: time.Hour}, newHotel(), from)
+	order := createOrder(t, manager, "key", luxPlace(from, 1))
+
+	_, err := manager.UpdateOrder(booking.NewContextWithIdempotencyKey(context.Background(), "key"), order.ID,
+		&booking.UpdateOrderInput{Places: []booking.Place{luxPlace(from, 2)}})
+	if booking.IsInputError(err) == nil {
+		t.Errorf("update with the key of order creation got %v, expected input error", err)
+	}
+}
+
+func TestManager_PurgeExpiredIdempotencyKeys(t *testing.T) {
+	t.Parallel()
+
+	from := startDate()
+	//nolint:exhaustruct
+	manager, _ := newManager(t, booking.Config{IdempotencyKeyTTL: 10 * time.Millisecond}, newHotel(), from)
+
+	first := createOrder(t, manager, "key", luxPlace(from, 1))
+
+	purged, err := manager.PurgeExpiredIdempotencyKeys(context.Background())
+	if err != nil {
+		t.Fatalf("purge keys: %v", err)
+	}
+
+	if purged != 0 {
+		t.Errorf("%v keys purged before TTL is over, expected 0", purged)
+	}
+
+	time.Sleep(20 * time.Millisecond)
+
+	purged, err = manager.PurgeExpiredIdempotencyKeys(context.Background())
+	if err != nil {
+		t.Fatalf("purge keys: %v", err)
+	}
+
+	if purged != 1 {
+		t.Errorf("%v keys purged after TTL is over, expected 1", purged)
+	}
+
+	second := createOrder(t, manager, "key", luxPlace(from, 2))
+	if second.ID == first.ID {
+		t.Errorf("purged key replayed order %v", first.ID)
+	}
+}
+
+func TestManager_CreateOrderReplaysUsedUpPromo(t *testing.T) {
+	t.Parallel()
+
+	from := startDate()
+	db := newDB(t, newHotel(), from)
+	//nolint:exhaustruct
+	savePromo(t, db, &boost.Promo{Code: "once", DiscountType: boost.DiscountPercent, Percentage: 10, MaxRedemptionsPerPayer: 1})
+	//nolint:exhaustruct
+	manager := newBoostedManager(db, boost.Config{})
+
+	book := func(key string) (*booking.Order, error) {
+		//nolint:exhaustruct
+		return manager.CreateOrder(booking.NewContextWithIdempotencyKey(context.Background(), key), &booking.BookInput{
+			Payer:      booking.Payer{Email: "guest@mail.ru"},
+			Places:     []booking.Place{luxPlace(from, 1)},
+			PromoCodes: []string{"once"},
+		})
+	}
+
+	first, err := book("first")
+	if err != nil {
+		t.Fatalf("create order: %v", err)
+	}
+
+	// The code is used up by the first order, but its retry is a replay.
+	retried, err := book("first")
+	if err != nil {
+		t.Fatalf("retry order: %v", err)
+	}
+
+	if retried.ID != first.ID {
+		t.Errorf("retry created order %v, expected replay of order %v", retried.ID, first.ID)
+	}
+
+	_, err = book("second")
+	if limitErr := booking.IsPromoLimitError(err); limitErr == nil || !limitErr.PerPayer {
+		t.Errorf("second usage of the code got %v, expected per payer limit error", err)
+	}
+}
+
+func TestManager_CreateOrderReplaysDefaultOccupancy(t *testing.T) {
+	t.Parallel()
+
+	from := startDate()
+	//nolint:exhaustruct
+	manager, _ := newManager(t, booking.Config{IdempotencyKeyTTL: time.Hour}, newHotel(), from)
+	ctx := booking.NewContextWithIdempotencyKey(context.Background(), "key")
+
+	unset := luxPlace(from, 1)
+	unset.Occupancy = booking.Occupancy{Adults: 0, Children: 0}
+
+	//nolint:exhaustruct
+	first, err := manager.CreateOrder(ctx, &booking.BookInput{
+		Payer:  booking.Payer{Email: "guest@mail.ru"},
+		Places: []booking.Place{unset},
+	})
+	if err != nil {
+		t.Fatalf("create order: %v", err)
+	}
+
+	// One adult is the occupancy of a place without one, so the retry is the same request.
+	place := luxPlace(from, 1)
+	place.Occupancy = booking.Occupancy{Adults: 1, Children: 0}
+
+	//nolint:exhaustruct
+	retried, err := manager.CreateOrder(ctx, &booking.BookInput{
+		Payer:  booking.Payer{Email: "guest@mail.ru"},
+		Places: []booking.Place{place},
+	})
+	if err != nil {
+		t.Fatalf("retry order: %v", err)
+	}
+
+	if retried.ID != first.ID {
+		t.Errorf("retry created order %v, expected replay of order %v", retried.ID, first.ID)
+	}
+}
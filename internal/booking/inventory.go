@@ -0,0 +1,202 @@
+package booking
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// maxInventoryNights limits the date range of one inventory change.
+const maxInventoryNights = 366
+
+type InventoryOperation string
+
+const (
+	// InventorySet makes Quota rooms available for sale on every night.
+	InventorySet InventoryOperation = "set"
+	// InventoryIncrement adds Quota rooms for sale on every night.
+	InventoryIncrement InventoryOperation = "increment"
+	// InventoryDecrement takes Quota rooms off sale on every night.
+	InventoryDecrement InventoryOperation = "decrement"
+)
+
+// InventoryInput changes quota of a room for nights in [From, To). Quota is the number of rooms left for sale,
+// rooms of existing orders are not part of it: a decrease which would take sold rooms back is refused.
+type InventoryInput struct {
+	HotelID   string             `json:"hotel_id"`
+	RoomID    string             `json:"room_id"`
+	From      time.Time          `json:"from"`
+	To        time.Time          `json:"to"`
+	Operation InventoryOperation `json:"operation"`
+	Quota     int                `json:"quota"`
+}
+
+// validate checks the input against the hotel, which may be nil when it is not in the catalog. Inventory
+// of inactive hotels and rooms can be changed, e.g. to prepare them for sales.
+func (i *InventoryInput) validate(hotel *Hotel) error {
+	inputErr := NewInputError()
+
+	switch {
+	case i.HotelID == "":
+		inputErr.AddError("hotel_id", "provide hotel_id")
+	case hotel == nil:
+		inputErr.AddError("hotel_id", fmt.Sprintf("hotel '%v' does not exist", i.HotelID))
+	case i.RoomID != "" && hotel.room(i.RoomID) == nil:
+		inputErr.AddError("room_id", fmt.Sprintf("room '%v' does not exist in hotel '%v'", i.RoomID, i.HotelID))
+	}
+
+	if i.RoomID == "" {
+		inputErr.AddError("room_id", "provide room_id")
+	}
+
+	switch {
+	case i.From.IsZero() || i.To.IsZero():
+		inputErr.AddError("from", "provide from and to dates")
+	case !i.To.After(i.From):
+		inputErr.AddError("to", "to must be at least one night after from")
+	case i.To.Sub(i.From) > maxInventoryNights*24*time.Hour:
+		inputErr.AddError("to", fmt.Sprintf("change at most %v nights at once", maxInventoryNights))
+	case hotel != nil && hotel.date(i.From).Before(hotel.today()):
+		inputErr.AddError("from", "from must not be in the past")
+	}
+
+	switch i.Operation {
+	case InventorySet:
+		if i.Quota < 0 {
+			inputErr.AddError("quota", "quota must not be negative")
+		}
+	case InventoryIncrement, InventoryDecrement:
+		if i.Quota < 1 {
+			inputErr.AddError("quota", "quota must be at least 1")
+		}
+	default:
+		inputErr.AddError("operation", fmt.Sprintf("operation must be one of %v, %v, %v", InventorySet, InventoryIncrement, InventoryDecrement))
+	}
+
+	if inputErr.FieldsCount() > 0 {
+		return inputErr
+	}
+
+	return nil
+}
+
+// ChangeInventory sets, increments or decrements quota of a room over a date range and records the change as
+// an event. Increments and decrements are applied relative to the current quota, so rooms booked concurrently
+// are never given back to sale. A set is calculated again when the quota changes before it is committed.
+func (m *Manager) ChangeInventory(ctx context.Context, input *InventoryInput) ([]*RoomAvailability, error) {
+	//nolint:exhaustruct // only the hotel is needed
+	hotels, err := m.getHotels(ctx, []Place{{HotelID: input.HotelID}})
+	if err != nil {
+		return nil, fmt.Errorf("get hotels: %w", err)
+	}
+
+	hotel := hotels[input.HotelID]
+
+	if err := input.validate(hotel); err != nil {
+		return nil, err
+	}
+
+	input.From, input.To = hotel.date(input.From), hotel.date(input.To)
+
+	var availabilities []*RoomAvailability
+
+	err = m.retryOnConflict(ctx, func() error {
+		availabilities, err = m.changeInventory(ctx, input)
+
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return availabilities, nil
+}
+
+func (m *Manager) changeInventory(ctx context.Context, input *InventoryInput) ([]*RoomAvailability, error) {
+	current, err := m.storage.SearchAvailabilities(ctx, AvailabilityFilter{
+		HotelIDs: []string{input.HotelID},
+		RoomIDs:  []string{input.RoomID},
+		From:     input.From,
+		To:       input.To,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("search availabilities in storage: %w", err)
+	}
+
+	quotas := make(map[string]int, len(current))
+	for _, availability := range current {
+		quotas[availabilityKey(availability.HotelID, availability.RoomID, availability.Date)] = availability.Quota
+	}
+
+	var (
+		changes      []QuotaChange
+		result       []*RoomAvailability
+		soldOutDates []time.Time
+	)
+
+	for d := input.From; d.Before(input.To); d = d.AddDate(0, 0, 1) {
+		// Nights which are not on sale yet have no quota and are created by AdjustQuotas.
+		quota := quotas[availabilityKey(input.HotelID, input.RoomID, d)]
+		delta := input.delta(quota)
+		if quota+delta < 0 {
+			soldOutDates = append(soldOutDates, d)
+
+			continue
+		}
+
+		//nolint:exhaustruct // relative changes apply to any quota
+		change := QuotaChange{HotelID: input.HotelID, RoomID: input.RoomID, Date: d, Delta: delta}
+		if input.Operation == InventorySet {
+			// The set quota is a delta from the read one, which must not change until commit.
+			change.Expected = &quota
+		}
+
+		changes = append(changes, change)
+		result = append(result, &RoomAvailability{HotelID: input.HotelID, RoomID: input.RoomID, Date: d, Quota: quota + delta})
+	}
+
+	if len(soldOutDates) > 0 {
+		return nil, &OverbookingError{HotelID: input.HotelID, RoomID: input.RoomID, Dates: soldOutDates}
+	}
+
+	event, err := m.buildEvent(ctx, 0, EventInventoryChanged, "", "")
+	if err != nil {
+		return nil, fmt.Errorf("build inventory event: %w", err)
+	}
+
+	event.Inventory = input
+	event.QuotaChanges = changes
+
+	err = m.inTransaction(ctx, func(ctx context.Context) error {
+		// Commit fails with ErrConflict when concurrent bookings took the rooms which were to be taken off sale
+		// or changed the quota which was set.
+		if err := m.storage.AdjustQuotas(ctx, changes); err != nil {
+			return fmt.Errorf("change quotas in storage: %w", err)
+		}
+
+		if err := m.storage.SaveEvent(ctx, event); err != nil {
+			return fmt.Errorf("save event to storage: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// delta returns how the quota of a night changes.
+func (i *InventoryInput) delta(quota int) int {
+	switch i.Operation {
+	case InventorySet:
+		return i.Quota - quota
+	case InventoryDecrement:
+		return -i.Quota
+	case InventoryIncrement:
+		return i.Quota
+	}
+
+	return 0
+}
@@ -0,0 +1,196 @@
+package booking_test
+
+import (
+	"context"
+	"io"
+	"log"
+	"slices"
+	"testing"
+
+	"github.com/avstrong/booking/internal/booking"
+	"github.com/avstrong/booking/internal/idgen/simple"
+	"github.com/avstrong/booking/internal/logger"
+	"github.com/avstrong/booking/internal/storage/memory"
+)
+
+// eventRecorder is memory storage which keeps saved events for inspection.
+type eventRecorder struct {
+	*memory.DB
+	events []*booking.Event
+}
+
+func (r *eventRecorder) SaveEvent(ctx context.Context, event *booking.Event) error {
+	r.events = append(r.events, event)
+
+	return r.DB.SaveEvent(ctx, event)
+}
+
+func TestManager_ChangeInventory(t *testing.T) {
+	t.Parallel()
+
+	from := startDate()
+
+	tests := []struct {
+		name      string
+		operation booking.InventoryOperation
+		quota     int
+		// quotas are expected for nights of newDB, where the first one has a room booked.
+		quotas []int
+	}{
+		{name: "set", operation: booking.InventorySet, quota: 2, quotas: []int{2, 2, 2}},
+		{name: "set to zero", operation: booking.InventorySet, quota: 0, quotas: []int{0, 0, 0}},
+		{name: "increment", operation: booking.InventoryIncrement, quota: 2, quotas: []int{quota + 1, quota + 2, quota + 2}},
+		{name: "decrement", operation: booking.InventoryDecrement, quota: 2, quotas: []int{quota - 3, quota - 2, quota - 2}},
+		{name: "decrement of all unsold rooms", operation: booking.InventoryDecrement, quota: quota - 1, quotas: []int{0, 1, 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			//nolint:exhaustruct
+			manager, db := newManager(t, booking.Config{}, newHotel(), from)
+			createOrder(t, manager, "create", luxPlace(from, 1))
+
+			availabilities, err := manager.ChangeInventory(context.Background(), &booking.InventoryInput{
+				HotelID:   "reddison",
+				RoomID:    "lux",
+				From:      from,
+				To:        from.AddDate(0, 0, nights),
+				Operation: tt.operation,
+				Quota:     tt.quota,
+			})
+			if err != nil {
+				t.Fatalf("change inventory: %v", err)
+			}
+
+			got := make([]int, 0, len(availabilities))
+			for _, availability := range availabilities {
+				got = append(got, availability.Quota)
+			}
+
+			if !slices.Equal(got, tt.quotas) {
+				t.Errorf("changed quotas are %v, expected %v", got, tt.quotas)
+			}
+
+			if stored := quotas(t, db, from); !slices.Equal(stored, tt.quotas) {
+				t.Errorf("stored quotas are %v, expected %v", stored, tt.quotas)
+			}
+		})
+	}
+}
+
+func TestManager_ChangeInventoryPutsNightsOnSale(t *testing.T) {
+	t.Parallel()
+
+	from := startDate()
+	//nolint:exhaustruct
+	manager, db := newManager(t, booking.Config{}, newHotel(), from)
+
+	// Only the first night is on sale, the others are created.
+	_, err := manager.ChangeInventory(context.Background(), &booking.InventoryInput{
+		HotelID:   "reddison",
+		RoomID:    "lux",
+		From:      from.AddDate(0, 0, nights-1),
+		To:        from.AddDate(0, 0, nights+2),
+		Operation: booking.InventorySet,
+		Quota:     2,
+	})
+	if err != nil {
+		t.Fatalf("change inventory: %v", err)
+	}
+
+	availabilities, err := db.GetRoomAvailabilities(context.Background(), []booking.GetAvailabilityInput{{
+		HotelID: "reddison",
+		RoomID:  "lux",
+		From:    from.AddDate(0, 0, nights-1),
+		To:      from.AddDate(0, 0, nights+2),
+	}})
+	if err != nil {
+		t.Fatalf("get room availabilities: %v", err)
+	}
+
+	if len(availabilities) != 3 {
+		t.Fatalf("nights on sale are %+v, expected 3", availabilities)
+	}
+
+	for _, availability := range availabilities {
+		if availability.Quota != 2 {
+			t.Errorf("quota on %v is %v, expected 2", availability.Date, availability.Quota)
+		}
+	}
+}
+
+func TestManager_ChangeInventoryRejectsOverbooking(t *testing.T) {
+	t.Parallel()
+
+	from := startDate()
+	//nolint:exhaustruct
+	manager, db := newManager(t, booking.Config{}, newHotel(), from)
+	createOrder(t, manager, "create", luxPlace(from.AddDate(0, 0, 1), 1))
+
+	// Only the second night has a room sold, so only it has less than the full quota unsold.
+	_, err := manager.ChangeInventory(context.Background(), &booking.InventoryInput{
+		HotelID:   "reddison",
+		RoomID:    "lux",
+		From:      from,
+		To:        from.AddDate(0, 0, nights),
+		Operation: booking.InventoryDecrement,
+		Quota:     quota,
+	})
+
+	overbookingErr := booking.IsOverbookingError(err)
+	if overbookingErr == nil {
+		t.Fatalf("decrement of sold rooms got %v, expected overbooking error", err)
+	}
+
+	if len(overbookingErr.Dates) != 1 || !overbookingErr.Dates[0].Equal(from.AddDate(0, 0, 1)) {
+		t.Errorf("overbooked dates are %v, expected only the second night", overbookingErr.Dates)
+	}
+
+	want := []int{quota, quota - 1, quota}
+	if got := quotas(t, db, from); !slices.Equal(got, want) {
+		t.Errorf("quotas are %v after a refused change, expected %v", got, want)
+	}
+}
+
+func TestManager_ChangeInventorySavesEvent(t *testing.T) {
+	t.Parallel()
+
+	from := startDate()
+	db := &eventRecorder{DB: newDB(t, newHotel(), from), events: nil}
+	//nolint:exhaustruct
+	manager := booking.New(booking.Config{L: logger.New(log.New(io.Discard, "", 0))}, db, simple.New(), nil, nil)
+
+	input := &booking.InventoryInput{
+		HotelID:   "reddison",
+		RoomID:    "lux",
+		From:      from,
+		To:        from.AddDate(0, 0, 2),
+		Operation: booking.InventorySet,
+		Quota:     3,
+	}
+
+	if _, err := manager.ChangeInventory(context.Background(), input); err != nil {
+		t.Fatalf("change inventory: %v", err)
+	}
+
+	if len(db.events) != 1 {
+		t.Fatalf("saved events are %+v, expected one", db.events)
+	}
+
+	event := db.events[0]
+	if event.Type != booking.EventInventoryChanged || event.Inventory != input {
+		t.Errorf("saved event is %v of %+v, expected %v of the input", event.Type, event.Inventory, booking.EventInventoryChanged)
+	}
+
+	if len(event.QuotaChanges) != 2 {
+		t.Fatalf("event has quota changes %+v, expected one for every night", event.QuotaChanges)
+	}
+
+	for _, change := range event.QuotaChanges {
+		if change.Delta != 3-quota || change.Expected == nil || *change.Expected != quota {
+			t.Errorf("quota change is %+v, expected delta %v from quota %v", change, 3-quota, quota)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package booking
+
+import (
+	"sort"
+	"time"
+)
+
+// night is a single hotel room night. Count is the number of rooms booked for it, or the
+// quota change when nights describe a difference between two sets of places.
+type night struct {
+	HotelID string
+	RoomID  string
+	Date    time.Time
+	Count   int
+}
+
+func countNights(places []Place) map[string]*night {
+	nights := make(map[string]*night)
+
+	for _, place := range places {
+		for d := place.From; d.Before(place.To); d = d.AddDate(0, 0, 1) {
+			key := availabilityKey(place.HotelID, place.RoomID, d)
+			if n, ok := nights[key]; ok {
+				n.Count++
+
+				continue
+			}
+
+			nights[key] = &night{
+				HotelID: place.HotelID,
+				RoomID:  place.RoomID,
+				Date:    d,
+				Count:   1,
+			}
+		}
+	}
+
+	return nights
+}
+
+// diffNights returns how the number of booked rooms changes per night when places
+// are replaced by updated ones. Nights without changes are omitted.
+func diffNights(places, updated []Place) map[string]*night {
+	diff := countNights(updated)
+
+	for key, n := range countNights(places) {
+		if d, ok := diff[key]; ok {
+			d.Count -= n.Count
+
+			if d.Count == 0 {
+				delete(diff, key)
+			}
+
+			continue
+		}
+
+		n.Count = -n.Count
+		diff[key] = n
+	}
+
+	return diff
+}
+
+func sortedNights(nights map[string]*night) []*night {
+	result := make([]*night, 0, len(nights))
+	for _, n := range nights {
+		result = append(result, n)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].HotelID != result[j].HotelID {
+			return result[i].HotelID < result[j].HotelID
+		}
+
+		if result[i].RoomID != result[j].RoomID {
+			return result[i].RoomID < result[j].RoomID
+		}
+
+		return result[i].Date.Before(result[j].Date)
+	})
+
+	return result
+}
+
+// nightInputs merges consecutive nights of the same room into availability requests.
+func nightInputs(nights []*night) []GetAvailabilityInput {
+	var inputs []GetAvailabilityInput
+
+	for _, n := range nights {
+		if last := len(inputs) - 1; last >= 0 &&
+			inputs[last].HotelID == n.HotelID &&
+			inputs[last].RoomID == n.RoomID &&
+			inputs[last].To.Equal(n.Date) {
+			inputs[last].To = n.Date.AddDate(0, 0, 1)
+
+			continue
+		}
+
+		inputs = append(inputs, GetAvailabilityInput{
+			HotelID: n.HotelID,
+			RoomID:  n.RoomID,
+			From:    n.Date,
+			To:      n.Date.AddDate(0, 0, 1),
+		})
+	}
+
+	return inputs
+}
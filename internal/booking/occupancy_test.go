@@ -0,0 +1,93 @@
+package booking_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/avstrong/booking/internal/booking"
+	"github.com/avstrong/booking/internal/money"
+)
+
+func TestManager_CreateOrderPricesOccupancy(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		occupancy booking.Occupancy
+		price     int64
+	}{
+		// No occupancy is one adult at the single price.
+		{occupancy: booking.Occupancy{Adults: 0, Children: 0}, price: 4500},
+		{occupancy: booking.Occupancy{Adults: 1, Children: 0}, price: 4500},
+		{occupancy: booking.Occupancy{Adults: 1, Children: 1}, price: 5000},
+		{occupancy: booking.Occupancy{Adults: 2, Children: 1}, price: 6500},
+		{occupancy: booking.Occupancy{Adults: 2, Children: 2}, price: 8000},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%v adults %v children", tt.occupancy.Adults, tt.occupancy.Children), func(t *testing.T) {
+			t.Parallel()
+
+			from := startDate()
+			//nolint:exhaustruct
+			manager, _ := newManager(t, booking.Config{IdempotencyKeyTTL: time.Hour}, newHotel(), from)
+
+			place := luxPlace(from, 2)
+			place.Occupancy = tt.occupancy
+
+			//nolint:exhaustruct
+			order, err := manager.CreateOrder(booking.NewContextWithIdempotencyKey(context.Background(), "key"), &booking.BookInput{
+				Payer:  booking.Payer{Email: "guest@mail.ru"},
+				Places: []booking.Place{place},
+			})
+			if err != nil {
+				t.Fatalf("create order: %v", err)
+			}
+
+			// Two nights at the nightly price.
+			if want := money.FromMajor(2*tt.price, "RUB"); order.Price != want {
+				t.Errorf("order price is %v, expected %v", order.Price, want)
+			}
+
+			if tt.occupancy.Guests() > 0 && order.Places[0].Occupancy != tt.occupancy {
+				t.Errorf("order occupancy is %+v, expected %+v", order.Places[0].Occupancy, tt.occupancy)
+			}
+		})
+	}
+}
+
+func TestManager_CreateOrderRejectsInvalidOccupancy(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		occupancy booking.Occupancy
+	}{
+		{name: "more guests than the room takes", occupancy: booking.Occupancy{Adults: 3, Children: 2}},
+		{name: "negative adults", occupancy: booking.Occupancy{Adults: -1, Children: 0}},
+		{name: "children without adults", occupancy: booking.Occupancy{Adults: 0, Children: 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			from := startDate()
+			//nolint:exhaustruct
+			manager, _ := newManager(t, booking.Config{IdempotencyKeyTTL: time.Hour}, newHotel(), from)
+
+			place := luxPlace(from, 1)
+			place.Occupancy = tt.occupancy
+
+			//nolint:exhaustruct
+			_, err := manager.CreateOrder(booking.NewContextWithIdempotencyKey(context.Background(), "key"), &booking.BookInput{
+				Payer:  booking.Payer{Email: "guest@mail.ru"},
+				Places: []booking.Place{place},
+			})
+			if booking.IsInputError(err) == nil {
+				t.Errorf("occupancy %+v got %v, expected input error", tt.occupancy, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,184 @@
+package booking
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultOrdersLimit = 20
+	maxOrdersLimit     = 100
+)
+
+// OrderFilter selects orders. Zero values of the fields mean "any".
+type OrderFilter struct {
+	PayerEmail string
+	HotelID    string
+	RoomID     string
+	// StayFrom and StayTo select orders with at least one night in [StayFrom, StayTo).
+	StayFrom    time.Time
+	StayTo      time.Time
+	CreatedFrom time.Time
+	CreatedTo   time.Time
+	Statuses    []OrderStatus
+	// AfterID makes storage return only orders with greater id. Orders are listed in ascending id order.
+	AfterID int
+	// Limit caps the number of returned orders. Zero means no limit.
+	Limit int
+}
+
+// Match reports whether the order satisfies all conditions of the filter except AfterID and Limit.
+func (f *OrderFilter) Match(order *Order) bool {
+	if f.PayerEmail != "" && normalizeEmail(f.PayerEmail) != normalizeEmail(order.Payer.Email) {
+		return false
+	}
+
+	if !f.CreatedFrom.IsZero() && order.CreatedAt.Before(f.CreatedFrom) {
+		return false
+	}
+
+	if !f.CreatedTo.IsZero() && order.CreatedAt.After(f.CreatedTo) {
+		return false
+	}
+
+	if len(f.Statuses) > 0 && !f.matchStatus(order.Status) {
+		return false
+	}
+
+	if f.HotelID == "" && f.RoomID == "" && f.StayFrom.IsZero() && f.StayTo.IsZero() {
+		return true
+	}
+
+	for _, place := range order.Places {
+		if f.matchPlace(place) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// normalizeEmail makes emails that differ only in case or surrounding spaces equal.
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+func (f *OrderFilter) matchStatus(status OrderStatus) bool {
+	for _, s := range f.Statuses {
+		if s == status {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (f *OrderFilter) matchPlace(place Place) bool {
+	if f.HotelID != "" && f.HotelID != place.HotelID {
+		return false
+	}
+
+	if f.RoomID != "" && f.RoomID != place.RoomID {
+		return false
+	}
+
+	if !f.StayFrom.IsZero() && !place.To.After(f.StayFrom) {
+		return false
+	}
+
+	if !f.StayTo.IsZero() && !place.From.Before(f.StayTo) {
+		return false
+	}
+
+	return true
+}
+
+type OrderPage struct {
+	Orders     []*Order `json:"orders"`
+	NextCursor string   `json:"next_cursor,omitempty"`
+}
+
+func encodeCursor(orderID int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.Itoa(orderID)))
+}
+
+func decodeCursor(cursor string) (int, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, fmt.Errorf("decode cursor: %w", err)
+	}
+
+	orderID, err := strconv.Atoi(string(raw))
+	if err != nil {
+		return 0, fmt.Errorf("parse cursor: %w", err)
+	}
+
+	return orderID, nil
+}
+
+func (m *Manager) GetOrder(ctx context.Context, id int) (*Order, error) {
+	order, err := m.storage.GetOrder(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("get order %v from storage: %w", id, err)
+	}
+
+	return order, nil
+}
+
+// ListOrders returns a page of orders matching the filter. The cursor is the NextCursor of the previous page.
+func (m *Manager) ListOrders(ctx context.Context, filter OrderFilter, cursor string) (*OrderPage, error) {
+	inputErr := NewInputError()
+
+	if cursor != "" {
+		afterID, err := decodeCursor(cursor)
+		if err != nil {
+			inputErr.AddError("cursor", "provide cursor returned by previous page")
+		}
+
+		filter.AfterID = afterID
+	}
+
+	for _, status := range filter.Statuses {
+		if !status.valid() {
+			inputErr.AddError("status", fmt.Sprintf("unknown status '%v'", status))
+		}
+	}
+
+	switch {
+	case filter.Limit < 0 || filter.Limit > maxOrdersLimit:
+		inputErr.AddError("limit", fmt.Sprintf("limit must be between 1 and %v", maxOrdersLimit))
+	case filter.Limit == 0:
+		filter.Limit = defaultOrdersLimit
+	}
+
+	if inputErr.FieldsCount() > 0 {
+		return nil, inputErr
+	}
+
+	filter.PayerEmail = normalizeEmail(filter.PayerEmail)
+
+	limit := filter.Limit
+	// One extra order tells whether there is a next page.
+	filter.Limit++
+
+	orders, err := m.storage.ListOrders(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("list orders from storage: %w", err)
+	}
+
+	page := &OrderPage{
+		Orders:     append([]*Order{}, orders...),
+		NextCursor: "",
+	}
+
+	if len(orders) > limit {
+		page.Orders = orders[:limit]
+		page.NextCursor = encodeCursor(page.Orders[limit-1].ID)
+	}
+
+	return page, nil
+}
@@ -0,0 +1,171 @@
+package booking_test
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/avstrong/booking/internal/booking"
+)
+
+func TestManager_ListOrdersPages(t *testing.T) {
+	t.Parallel()
+
+	from := startDate()
+	//nolint:exhaustruct
+	manager, _ := newManager(t, booking.Config{}, newHotel(), from)
+
+	ids := make([]int, 0, quota)
+	for i := 0; i < quota; i++ {
+		ids = append(ids, createOrder(t, manager, fmt.Sprint(i), luxPlace(from, 1)).ID)
+	}
+
+	tests := []struct {
+		limit int
+		pages [][]int
+	}{
+		{limit: 2, pages: [][]int{ids[:2], ids[2:4], ids[4:]}},
+		// The last page is full, so the extra order fetched to look ahead is not there.
+		{limit: quota, pages: [][]int{ids}},
+		{limit: quota - 1, pages: [][]int{ids[:quota-1], ids[quota-1:]}},
+		{limit: quota + 1, pages: [][]int{ids}},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.limit), func(t *testing.T) {
+			t.Parallel()
+
+			var cursor string
+
+			for idx, want := range tt.pages {
+				//nolint:exhaustruct
+				page, err := manager.ListOrders(context.Background(), booking.OrderFilter{Limit: tt.limit}, cursor)
+				if err != nil {
+					t.Fatalf("list page %v: %v", idx, err)
+				}
+
+				if got := orderIDs(page.Orders); !slices.Equal(got, want) {
+					t.Errorf("page %v has orders %v, expected %v", idx, got, want)
+				}
+
+				last := idx == len(tt.pages)-1
+				if last != (page.NextCursor == "") {
+					t.Fatalf("page %v has next cursor %q, expected it only before the last page", idx, page.NextCursor)
+				}
+
+				cursor = page.NextCursor
+			}
+		})
+	}
+}
+
+func TestManager_ListOrdersFilters(t *testing.T) {
+	t.Parallel()
+
+	from := startDate()
+	//nolint:exhaustruct
+	manager, _ := newManager(t, booking.Config{}, newHotel(), from)
+
+	book := func(key, email string, place booking.Place) int {
+		//nolint:exhaustruct
+		order, err := manager.CreateOrder(booking.NewContextWithIdempotencyKey(context.Background(), key), &booking.BookInput{
+			Payer:  booking.Payer{Email: email},
+			Places: []booking.Place{place},
+		})
+		if err != nil {
+			t.Fatalf("create order: %v", err)
+		}
+
+		return order.ID
+	}
+
+	first := book("first", "guest@mail.ru", luxPlace(from, 1))
+	second := book("second", "other@mail.ru", luxPlace(from.AddDate(0, 0, 1), 1))
+	third := book("third", "Guest@Mail.RU", luxPlace(from, 2))
+
+	if _, err := manager.CancelOrder(context.Background(), third); err != nil {
+		t.Fatalf("cancel order: %v", err)
+	}
+
+	//nolint:exhaustruct
+	tests := []struct {
+		name   string
+		filter booking.OrderFilter
+		want   []int
+	}{
+		{name: "no filter", want: []int{first, second, third}},
+		{name: "payer email of any case", filter: booking.OrderFilter{PayerEmail: " GUEST@mail.ru"}, want: []int{first, third}},
+		{name: "status", filter: booking.OrderFilter{Statuses: []booking.OrderStatus{booking.OrderStatusCancelled}}, want: []int{third}},
+		{
+			name:   "any of statuses",
+			filter: booking.OrderFilter{Statuses: []booking.OrderStatus{booking.OrderStatusPending, booking.OrderStatusCancelled}},
+			want:   []int{first, second, third},
+		},
+		{name: "hotel", filter: booking.OrderFilter{HotelID: "other"}, want: nil},
+		{name: "room", filter: booking.OrderFilter{HotelID: "reddison", RoomID: "lux"}, want: []int{first, second, third}},
+		{name: "stays until the date", filter: booking.OrderFilter{StayFrom: from.AddDate(0, 0, 1)}, want: []int{second, third}},
+		{name: "stays from the date", filter: booking.OrderFilter{StayTo: from.AddDate(0, 0, 1)}, want: []int{first, third}},
+		{name: "created before", filter: booking.OrderFilter{CreatedFrom: time.Now().Add(time.Hour)}, want: nil},
+		{name: "created after", filter: booking.OrderFilter{CreatedTo: time.Now().Add(-time.Hour)}, want: nil},
+		{name: "payer and status", filter: booking.OrderFilter{
+			PayerEmail: "guest@mail.ru",
+			Statuses:   []booking.OrderStatus{booking.OrderStatusPending},
+		}, want: []int{first}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			page, err := manager.ListOrders(context.Background(), tt.filter, "")
+			if err != nil {
+				t.Fatalf("list orders: %v", err)
+			}
+
+			if got := orderIDs(page.Orders); !slices.Equal(got, tt.want) {
+				t.Errorf("listed orders %v, expected %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestManager_ListOrdersRejectsInvalidFilter(t *testing.T) {
+	t.Parallel()
+
+	//nolint:exhaustruct
+	tests := []struct {
+		name   string
+		filter booking.OrderFilter
+		cursor string
+	}{
+		{name: "cursor", cursor: "not a cursor"},
+		{name: "status", filter: booking.OrderFilter{Statuses: []booking.OrderStatus{"lost"}}},
+		{name: "negative limit", filter: booking.OrderFilter{Limit: -1}},
+		{name: "limit over maximum", filter: booking.OrderFilter{Limit: 101}},
+	}
+
+	//nolint:exhaustruct
+	manager, _ := newManager(t, booking.Config{}, newHotel(), startDate())
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if _, err := manager.ListOrders(context.Background(), tt.filter, tt.cursor); booking.IsInputError(err) == nil {
+				t.Errorf("list orders got %v, expected input error", err)
+			}
+		})
+	}
+}
+
+func orderIDs(orders []*booking.Order) []int {
+	var ids []int
+
+	for _, order := range orders {
+		ids = append(ids, order.ID)
+	}
+
+	return ids
+}
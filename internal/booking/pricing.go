@@ -0,0 +1,175 @@
+package booking
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/avstrong/booking/internal/money"
+)
+
+// doubleOccupancy is the number of guests the main price of a rate is for.
+const doubleOccupancy = 2
+
+// priceFor returns the price of the night for the number of guests in the room.
+func (r *RoomRate) priceFor(guests int) (money.Money, error) {
+	switch {
+	case guests <= 1 && r.SinglePrice != nil:
+		return *r.SinglePrice, nil
+	case guests <= doubleOccupancy || r.ExtraBedPrice == nil:
+		return r.Price, nil
+	}
+
+	price, err := r.Price.Add(r.ExtraBedPrice.Mul(int64(guests - doubleOccupancy)))
+	if err != nil {
+		return money.Money{}, fmt.Errorf("add extra beds to rate of room %v in hotel %v: %w", r.RoomID, r.HotelID, err)
+	}
+
+	return price, nil
+}
+
+// priceOrder adds a night line item for every night of the order, sets the price of every place as the sum
+// of its nights and the order price as the sum of all line items. Nights are priced by the occupancy of their
+// places. Nights without a rate can not be sold, and all nights of the order have to be priced in one currency.
+func (m *Manager) priceOrder(ctx context.Context, order *Order) error {
+	found, err := m.storage.GetRates(ctx, nightInputs(sortedNights(countNights(order.Places))))
+	if err != nil {
+		return fmt.Errorf("get rates from storage: %w", err)
+	}
+
+	rates := make(map[string]*RoomRate, len(found))
+	for _, rate := range found {
+		rates[availabilityKey(rate.HotelID, rate.RoomID, rate.Date)] = rate
+	}
+
+	availabilityErr := NewAvailabilityError()
+	items := make([]LineItem, 0, len(found))
+
+	for idx := range order.Places {
+		place := &order.Places[idx]
+
+		var (
+			unpricedDates []time.Time
+			nightPrices   []money.Money
+		)
+
+		for d := place.From; d.Before(place.To); d = d.AddDate(0, 0, 1) {
+			rate, ok := rates[availabilityKey(place.HotelID, place.RoomID, d)]
+			if !ok {
+				unpricedDates = append(unpricedDates, d)
+
+				continue
+			}
+
+			price, err := rate.priceFor(int(place.guests()))
+			if err != nil {
+				return err
+			}
+
+			date := d
+
+			//nolint:exhaustruct // strategy is set for discounts only
+			items = append(items, LineItem{
+				Type:    LineItemNight,
+				HotelID: place.HotelID,
+				RoomID:  place.RoomID,
+				Date:    &date,
+				Amount:  price,
+			})
+			nightPrices = append(nightPrices, price)
+		}
+
+		if len(unpricedDates) > 0 {
+			availabilityErr.AddUnpricedRoom(place.HotelID, place.RoomID, unpricedDates)
+
+			continue
+		}
+
+		if place.Price, err = money.Sum(nightPrices...); err != nil {
+			return currencyError(err)
+		}
+	}
+
+	if availabilityErr.UnavailableRoomsCount() > 0 {
+		return availabilityErr
+	}
+
+	order.LineItems = items
+
+	if err := order.total(); err != nil {
+		return currencyError(err)
+	}
+
+	return nil
+}
+
+// priceNewOrder runs the whole pricing pipeline for a booking request: nightly rates, boosts, taxes and
+// the display currency. Quotes use it too, so a quote always matches what booking would charge.
+func (m *Manager) priceNewOrder(ctx context.Context, order *Order, input *BookInput, hotels map[string]*Hotel) error {
+	if err := m.priceOrder(ctx, order); err != nil {
+		return fmt.Errorf("price order: %w", err)
+	}
+
+	strategies, err := m.boostStrategies(ctx, input)
+	if err != nil {
+		return err
+	}
+
+	if err := order.applyBoosts(strategies); err != nil {
+		return err
+	}
+
+	if err := order.applyTaxes(hotels); err != nil {
+		return fmt.Errorf("apply taxes: %w", err)
+	}
+
+	if err := m.convertPrice(ctx, order, input.DisplayCurrency); err != nil {
+		return fmt.Errorf("convert price: %w", err)
+	}
+
+	return nil
+}
+
+// total sets the order price as the sum of its line items which are not included in others.
+func (o *Order) total() error {
+	amounts := make([]money.Money, 0, len(o.LineItems))
+	for _, item := range o.LineItems {
+		if !item.Included {
+			amounts = append(amounts, item.Amount)
+		}
+	}
+
+	price, err := money.Sum(amounts...)
+	if err != nil {
+		return err //nolint:wrapcheck // callers wrap it
+	}
+
+	o.Price = price
+
+	return nil
+}
+
+func currencyError(err error) error {
+	if !errors.Is(err, money.ErrCurrencyMismatch) {
+		return err
+	}
+
+	inputErr := NewInputError()
+	inputErr.AddError("places", fmt.Sprintf("places of one order must be priced in one currency: %v", err))
+
+	return inputErr
+}
+
+func (o *Order) promoRedemptions() []PromoRedemption {
+	redemptions := make([]PromoRedemption, 0, len(o.PromoCodes))
+	for _, code := range o.PromoCodes {
+		redemptions = append(redemptions, PromoRedemption{
+			Code:       code,
+			OrderID:    o.ID,
+			PayerEmail: normalizeEmail(o.Payer.Email),
+		})
+	}
+
+	return redemptions
+}
@@ -0,0 +1,93 @@
+package booking
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+func quotaChanges(nights map[string]*night, sign int) []QuotaChange {
+	sorted := sortedNights(nights)
+	changes := make([]QuotaChange, 0, len(sorted))
+
+	for _, n := range sorted {
+		//nolint:exhaustruct // booked nights may take any quota left
+		changes = append(changes, QuotaChange{
+			HotelID: n.HotelID,
+			RoomID:  n.RoomID,
+			Date:    n.Date,
+			Delta:   sign * n.Count,
+		})
+	}
+
+	return changes
+}
+
+// checkAvailability makes sure every night has enough quota for the number of rooms booked on it.
+// It is an early check only: quota is finally checked by storage on commit.
+func (m *Manager) checkAvailability(ctx context.Context, nights map[string]*night) error {
+	sorted := sortedNights(nights)
+
+	availabilities, err := m.storage.GetAvailabilities(ctx, nightInputs(sorted))
+	if err != nil {
+		return fmt.Errorf("get availabilities from storage: %w", err)
+	}
+
+	quotas := make(map[string]int, len(availabilities))
+	for _, availability := range availabilities {
+		quotas[availabilityKey(availability.HotelID, availability.RoomID, availability.Date)] = availability.Quota
+	}
+
+	availabilityErr := NewAvailabilityError()
+
+	var unavailableDates []time.Time
+
+	for idx, n := range sorted {
+		if quotas[availabilityKey(n.HotelID, n.RoomID, n.Date)] < n.Count {
+			unavailableDates = append(unavailableDates, n.Date)
+		}
+
+		last := idx == len(sorted)-1
+		if last || sorted[idx+1].HotelID != n.HotelID || sorted[idx+1].RoomID != n.RoomID {
+			if len(unavailableDates) > 0 {
+				availabilityErr.AddUnavailableRoom(n.HotelID, n.RoomID, unavailableDates)
+				unavailableDates = nil
+			}
+		}
+	}
+
+	if availabilityErr.UnavailableRoomsCount() > 0 {
+		return availabilityErr
+	}
+
+	return nil
+}
+
+// retryOnConflict repeats fn while storage reports that the same data was changed concurrently.
+func (m *Manager) retryOnConflict(ctx context.Context, fn func() error) error {
+	attempts := max(m.conf.CommitAttempts, 1)
+
+	var err error
+
+	for attempt := 1; attempt <= attempts; attempt++ {
+		err = fn()
+		if !errors.Is(err, ErrConflict) {
+			return err
+		}
+
+		m.l.LogInfo("Conflict on attempt %v of %v: %v", attempt, attempts, err.Error())
+
+		if attempt == attempts {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("wait for next attempt: %w", ctx.Err())
+		case <-time.After(m.conf.CommitRetryDelay * time.Duration(attempt)):
+		}
+	}
+
+	return err
+}
@@ -0,0 +1,99 @@
+package booking
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/avstrong/booking/internal/money"
+)
+
+// Quote is what booking the input would charge now. A quote is not saved and takes neither quota
+// nor promo code usages.
+type Quote struct {
+	// Available is false when some nights can not be booked; Unavailable explains which ones.
+	Available   bool     `json:"available"`
+	Unavailable []string `json:"unavailable,omitempty"`
+	// Violations are stay restrictions which forbid the booking.
+	Violations []RestrictionViolation `json:"violations,omitempty"`
+	Places     []Place                `json:"places"`
+	LineItems  []LineItem             `json:"line_items"`
+	Price      money.Money            `json:"price"`
+	Display    *DisplayPrice          `json:"display,omitempty"`
+	// RejectedBoosts explain why some boost strategies were not applied.
+	RejectedBoosts []RejectedBoost `json:"rejected_boosts,omitempty"`
+}
+
+// Quote runs the same validation, availability check and pricing as CreateOrder without booking anything.
+// Unavailable nights do not fail the quote: it is priced when the nights have rates and reports them.
+func (m *Manager) Quote(ctx context.Context, input *BookInput) (*Quote, error) {
+	hotels, err := m.getHotels(ctx, input.Places)
+	if err != nil {
+		return nil, fmt.Errorf("get hotels: %w", err)
+	}
+
+	if err := input.validate(hotels); err != nil {
+		return nil, err
+	}
+
+	input.preparePlaces(hotels)
+
+	//nolint:exhaustruct // filled below
+	quote := &Quote{
+		Available: true,
+		Places:    input.Places,
+	}
+
+	if err := m.checkAvailability(ctx, countNights(input.Places)); err != nil {
+		if !quote.addUnavailable(err) {
+			return nil, fmt.Errorf("check availability: %w", err)
+		}
+	}
+
+	if err := m.checkRestrictions(ctx, input.Places); err != nil {
+		if !quote.addUnavailable(err) {
+			return nil, fmt.Errorf("check restrictions: %w", err)
+		}
+	}
+
+	//nolint:exhaustruct // the order is not booked, so it has no id, status and dates
+	order := &Order{
+		Payer:  input.Payer,
+		Places: input.Places,
+	}
+
+	if err := m.priceNewOrder(ctx, order, input, hotels); err != nil {
+		if !quote.addUnavailable(err) {
+			return nil, err
+		}
+
+		return quote, nil
+	}
+
+	quote.Places = order.Places
+	quote.LineItems = order.LineItems
+	quote.Price = order.Price
+	quote.Display = order.Display
+	quote.RejectedBoosts = order.RejectedBoosts
+
+	return quote, nil
+}
+
+// addUnavailable reports an availability or a restriction error on the quote.
+func (q *Quote) addUnavailable(err error) bool {
+	if restrictionErr := IsRestrictionError(err); restrictionErr != nil {
+		q.Available = false
+		q.Violations = append(q.Violations, restrictionErr.Violations...)
+
+		return true
+	}
+
+	availabilityErr := IsAvailabilityError(err)
+	if availabilityErr == nil {
+		return false
+	}
+
+	q.Available = false
+	q.Unavailable = append(q.Unavailable, availabilityErr.Fields()...)
+
+	return true
+}
@@ -0,0 +1,125 @@
+package booking_test
+
+import (
+	"context"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/avstrong/booking/internal/booking"
+	"github.com/avstrong/booking/internal/boost"
+	"github.com/avstrong/booking/internal/money"
+)
+
+func TestManager_QuoteMatchesBooking(t *testing.T) {
+	t.Parallel()
+
+	hotel := newHotel()
+	hotel.Taxes = []booking.TaxRule{
+		{Code: "vat", Kind: booking.TaxKindPercentage, Percentage: 20, Inclusive: true},
+		{Code: "cleaning", Kind: booking.TaxKindPerNight, Fee: true, Amount: money.FromMajor(300, "RUB")},
+	}
+
+	from := startDate()
+	//nolint:exhaustruct
+	manager, db := newManager(t, booking.Config{}, hotel, from)
+
+	//nolint:exhaustruct
+	quote, err := manager.Quote(context.Background(), &booking.BookInput{
+		Payer:  booking.Payer{Email: "guest@mail.ru"},
+		Places: []booking.Place{luxPlace(from, 2)},
+	})
+	if err != nil {
+		t.Fatalf("quote: %v", err)
+	}
+
+	if !quote.Available || len(quote.Unavailable) > 0 {
+		t.Errorf("quote is available=%v with unavailable %v, expected available", quote.Available, quote.Unavailable)
+	}
+
+	if got, want := quotas(t, db, from), []int{quota, quota, quota}; !slices.Equal(got, want) {
+		t.Errorf("quotas are %v after a quote, expected %v", got, want)
+	}
+
+	order := createOrder(t, manager, "key", luxPlace(from, 2))
+
+	if quote.Price != order.Price {
+		t.Errorf("quote price is %v, booked at %v", quote.Price, order.Price)
+	}
+
+	if !reflect.DeepEqual(quote.LineItems, order.LineItems) {
+		t.Errorf("quote has line items %+v, booked with %+v", quote.LineItems, order.LineItems)
+	}
+
+	if got, want := quotas(t, db, from), []int{quota - 1, quota - 1, quota}; !slices.Equal(got, want) {
+		t.Errorf("quotas are %v after booking, expected %v", got, want)
+	}
+}
+
+func TestManager_QuoteReportsUnavailableNights(t *testing.T) {
+	t.Parallel()
+
+	from := startDate()
+	//nolint:exhaustruct
+	manager, _ := newManager(t, booking.Config{}, newHotel(), from)
+
+	// Nights after the third one are not on sale.
+	//nolint:exhaustruct
+	quote, err := manager.Quote(context.Background(), &booking.BookInput{
+		Payer:  booking.Payer{Email: "guest@mail.ru"},
+		Places: []booking.Place{luxPlace(from, nights+1)},
+	})
+	if err != nil {
+		t.Fatalf("quote: %v", err)
+	}
+
+	if quote.Available || len(quote.Unavailable) == 0 {
+		t.Errorf("quote is available=%v with unavailable %v, expected unavailable nights", quote.Available, quote.Unavailable)
+	}
+}
+
+func TestManager_QuoteDoesNotRedeemPromo(t *testing.T) {
+	t.Parallel()
+
+	from := startDate()
+	db := newDB(t, newHotel(), from)
+	//nolint:exhaustruct
+	savePromo(t, db, &boost.Promo{Code: "once", DiscountType: boost.DiscountPercent, Percentage: 10, MaxRedemptions: 1})
+	//nolint:exhaustruct
+	manager := newBoostedManager(db, boost.Config{})
+
+	//nolint:exhaustruct
+	input := booking.BookInput{
+		Payer:      booking.Payer{Email: "guest@mail.ru"},
+		Places:     []booking.Place{luxPlace(from, 1)},
+		PromoCodes: []string{"once"},
+	}
+
+	for i := 0; i < 2; i++ {
+		quoteInput := input
+
+		quote, err := manager.Quote(context.Background(), &quoteInput)
+		if err != nil {
+			t.Fatalf("quote: %v", err)
+		}
+
+		if want := money.FromMajor(4500, "RUB"); quote.Price != want {
+			t.Errorf("quote price is %v, expected %v", quote.Price, want)
+		}
+	}
+
+	// Quotes left the only redemption of the code to the booking.
+	order, err := manager.CreateOrder(booking.NewContextWithIdempotencyKey(context.Background(), "key"), &input)
+	if err != nil {
+		t.Fatalf("create order after quotes: %v", err)
+	}
+
+	if want := money.FromMajor(4500, "RUB"); order.Price != want {
+		t.Errorf("order price is %v, expected %v", order.Price, want)
+	}
+
+	_, err = manager.CreateOrder(booking.NewContextWithIdempotencyKey(context.Background(), "other"), &input)
+	if limitErr := booking.IsPromoLimitError(err); limitErr == nil || limitErr.PerPayer {
+		t.Errorf("second booking with the code got %v, expected total limit error", err)
+	}
+}
@@ -0,0 +1,285 @@
+package booking
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+type RestrictionType string
+
+const (
+	// RestrictionMinNights and RestrictionMaxNights limit the length of stays arriving on the date.
+	RestrictionMinNights RestrictionType = "min_nights"
+	RestrictionMaxNights RestrictionType = "max_nights"
+	// RestrictionClosedToArrival forbids stays arriving on the date.
+	RestrictionClosedToArrival RestrictionType = "closed_to_arrival"
+	// RestrictionClosedToDeparture forbids stays leaving on the date.
+	RestrictionClosedToDeparture RestrictionType = "closed_to_departure"
+	// RestrictionStopSell forbids stays including the night of the date.
+	RestrictionStopSell RestrictionType = "stop_sell"
+)
+
+// StayRestriction controls which stays of a room can be sold around a date. Zero MinNights and MaxNights
+// mean no limit.
+type StayRestriction struct {
+	HotelID           string    `json:"hotel_id"`
+	RoomID            string    `json:"room_id"`
+	Date              time.Time `json:"date"`
+	MinNights         int       `json:"min_nights,omitempty"`
+	MaxNights         int       `json:"max_nights,omitempty"`
+	ClosedToArrival   bool      `json:"closed_to_arrival,omitempty"`
+	ClosedToDeparture bool      `json:"closed_to_departure,omitempty"`
+	StopSell          bool      `json:"stop_sell,omitempty"`
+}
+
+// RestrictionViolation is a restriction which forbids a stay. Limit is set for length of stay restrictions.
+type RestrictionViolation struct {
+	HotelID     string          `json:"hotel_id"`
+	RoomID      string          `json:"room_id"`
+	Date        time.Time       `json:"date"`
+	Restriction RestrictionType `json:"restriction"`
+	Limit       int             `json:"limit,omitempty"`
+}
+
+func (v RestrictionViolation) String() string {
+	if v.Limit > 0 {
+		return fmt.Sprintf(
+			"room '%v' in hotel '%v' arriving on %v has %v %v",
+			v.RoomID, v.HotelID, v.Date.Format(time.DateOnly), v.Restriction, v.Limit,
+		)
+	}
+
+	return fmt.Sprintf("room '%v' in hotel '%v' is %v on %v", v.RoomID, v.HotelID, v.Restriction, v.Date.Format(time.DateOnly))
+}
+
+// restrictionInputs asks for restrictions of every night of the places and of their departure dates.
+func restrictionInputs(places []Place) []GetAvailabilityInput {
+	inputs := make([]GetAvailabilityInput, 0, len(places))
+
+	for _, place := range places {
+		inputs = append(inputs, GetAvailabilityInput{
+			HotelID: place.HotelID,
+			RoomID:  place.RoomID,
+			From:    place.From,
+			To:      place.To.AddDate(0, 0, 1),
+		})
+	}
+
+	return inputs
+}
+
+// restrictionViolations evaluates restrictions of the stay: length of stay and arrival restrictions of the arrival
+// date, the departure restriction of the departure date and stop-sell of every night.
+func (p *Place) restrictionViolations(restrictions map[string]*StayRestriction) []RestrictionViolation {
+	var violations []RestrictionViolation
+
+	violation := func(date time.Time, restriction RestrictionType, limit int) {
+		violations = append(violations, RestrictionViolation{
+			HotelID:     p.HotelID,
+			RoomID:      p.RoomID,
+			Date:        date,
+			Restriction: restriction,
+			Limit:       limit,
+		})
+	}
+
+	if arrival, ok := restrictions[availabilityKey(p.HotelID, p.RoomID, p.From)]; ok {
+		if arrival.ClosedToArrival {
+			violation(p.From, RestrictionClosedToArrival, 0)
+		}
+
+		if arrival.MinNights > 0 && p.Nights() < arrival.MinNights {
+			violation(p.From, RestrictionMinNights, arrival.MinNights)
+		}
+
+		if arrival.MaxNights > 0 && p.Nights() > arrival.MaxNights {
+			violation(p.From, RestrictionMaxNights, arrival.MaxNights)
+		}
+	}
+
+	for d := p.From; d.Before(p.To); d = d.AddDate(0, 0, 1) {
+		if night, ok := restrictions[availabilityKey(p.HotelID, p.RoomID, d)]; ok && night.StopSell {
+			violation(d, RestrictionStopSell, 0)
+		}
+	}
+
+	if departure, ok := restrictions[availabilityKey(p.HotelID, p.RoomID, p.To)]; ok && departure.ClosedToDeparture {
+		violation(p.To, RestrictionClosedToDeparture, 0)
+	}
+
+	return violations
+}
+
+func (m *Manager) getRestrictions(ctx context.Context, places []Place) (map[string]*StayRestriction, error) {
+	found, err := m.storage.GetRestrictions(ctx, restrictionInputs(places))
+	if err != nil {
+		return nil, fmt.Errorf("get restrictions from storage: %w", err)
+	}
+
+	restrictions := make(map[string]*StayRestriction, len(found))
+	for _, restriction := range found {
+		restrictions[availabilityKey(restriction.HotelID, restriction.RoomID, restriction.Date)] = restriction
+	}
+
+	return restrictions, nil
+}
+
+// checkRestrictions makes sure stay restrictions allow every place. Places are expected in hotel-local dates.
+func (m *Manager) checkRestrictions(ctx context.Context, places []Place) error {
+	restrictions, err := m.getRestrictions(ctx, places)
+	if err != nil {
+		return err
+	}
+
+	restrictionErr := NewRestrictionError()
+
+	for idx := range places {
+		restrictionErr.Violations = append(restrictionErr.Violations, places[idx].restrictionViolations(restrictions)...)
+	}
+
+	if len(restrictionErr.Violations) > 0 {
+		return restrictionErr
+	}
+
+	return nil
+}
+
+// joinBookingErrors returns errors of the availability and restriction checks together, so a booking reports
+// unavailable nights and broken restrictions at once like a quote does. Other errors are returned on their own.
+func joinBookingErrors(availabilityErr, restrictionErr error) error {
+	if availabilityErr != nil && IsAvailabilityError(availabilityErr) == nil {
+		return fmt.Errorf("check availability: %w", availabilityErr)
+	}
+
+	if restrictionErr != nil && IsRestrictionError(restrictionErr) == nil {
+		return fmt.Errorf("check restrictions: %w", restrictionErr)
+	}
+
+	return errors.Join(availabilityErr, restrictionErr)
+}
+
+// changedPlaces returns places which the order does not have yet. Restrictions are not applied to places
+// which were booked before they were set.
+func changedPlaces(places, updated []Place) []Place {
+	booked := make(map[string]bool, len(places))
+	for _, place := range places {
+		booked[placeKey(place)] = true
+	}
+
+	var changed []Place
+
+	for _, place := range updated {
+		if !booked[placeKey(place)] {
+			changed = append(changed, place)
+		}
+	}
+
+	return changed
+}
+
+func placeKey(place Place) string {
+	return fmt.Sprintf("%s_%s_%s_%s", place.HotelID, place.RoomID, place.From.Format(time.DateOnly), place.To.Format(time.DateOnly))
+}
+
+// RestrictionInput sets restrictions of a room for every date in [From, To), replacing restrictions set before.
+// An input with no restrictions lifts them.
+type RestrictionInput struct {
+	HotelID           string    `json:"hotel_id"`
+	RoomID            string    `json:"room_id"`
+	From              time.Time `json:"from"`
+	To                time.Time `json:"to"`
+	MinNights         int       `json:"min_nights"`
+	MaxNights         int       `json:"max_nights"`
+	ClosedToArrival   bool      `json:"closed_to_arrival"`
+	ClosedToDeparture bool      `json:"closed_to_departure"`
+	StopSell          bool      `json:"stop_sell"`
+}
+
+func (i *RestrictionInput) validate(hotel *Hotel) error {
+	inputErr := NewInputError()
+
+	switch {
+	case i.HotelID == "":
+		inputErr.AddError("hotel_id", "provide hotel_id")
+	case hotel == nil:
+		inputErr.AddError("hotel_id", fmt.Sprintf("hotel '%v' does not exist", i.HotelID))
+	case i.RoomID != "" && hotel.room(i.RoomID) == nil:
+		inputErr.AddError("room_id", fmt.Sprintf("room '%v' does not exist in hotel '%v'", i.RoomID, i.HotelID))
+	}
+
+	if i.RoomID == "" {
+		inputErr.AddError("room_id", "provide room_id")
+	}
+
+	switch {
+	case i.From.IsZero() || i.To.IsZero():
+		inputErr.AddError("from", "provide from and to dates")
+	case !i.To.After(i.From):
+		inputErr.AddError("to", "to must be at least one day after from")
+	case i.To.Sub(i.From) > maxInventoryNights*24*time.Hour:
+		inputErr.AddError("to", fmt.Sprintf("change at most %v days at once", maxInventoryNights))
+	case hotel != nil && hotel.date(i.From).Before(hotel.today()):
+		inputErr.AddError("from", "from must not be in the past")
+	}
+
+	if i.MinNights < 0 {
+		inputErr.AddError("min_nights", "min_nights must not be negative")
+	}
+
+	if i.MaxNights < 0 || (i.MaxNights > 0 && i.MaxNights < i.MinNights) {
+		inputErr.AddError("max_nights", "max_nights must not be negative or less than min_nights")
+	}
+
+	if inputErr.FieldsCount() > 0 {
+		return inputErr
+	}
+
+	return nil
+}
+
+// SetRestrictions replaces stay restrictions of a room over a date range. Existing orders are not affected.
+func (m *Manager) SetRestrictions(ctx context.Context, input *RestrictionInput) ([]*StayRestriction, error) {
+	//nolint:exhaustruct // only the hotel is needed
+	hotels, err := m.getHotels(ctx, []Place{{HotelID: input.HotelID}})
+	if err != nil {
+		return nil, fmt.Errorf("get hotels: %w", err)
+	}
+
+	hotel := hotels[input.HotelID]
+
+	if err := input.validate(hotel); err != nil {
+		return nil, err
+	}
+
+	from, to := hotel.date(input.From), hotel.date(input.To)
+
+	var restrictions []*StayRestriction
+
+	for d := from; d.Before(to); d = d.AddDate(0, 0, 1) {
+		restrictions = append(restrictions, &StayRestriction{
+			HotelID:           input.HotelID,
+			RoomID:            input.RoomID,
+			Date:              d,
+			MinNights:         input.MinNights,
+			MaxNights:         input.MaxNights,
+			ClosedToArrival:   input.ClosedToArrival,
+			ClosedToDeparture: input.ClosedToDeparture,
+			StopSell:          input.StopSell,
+		})
+	}
+
+	err = m.inTransaction(ctx, func(ctx context.Context) error {
+		if err := m.storage.SaveRestrictions(ctx, restrictions); err != nil {
+			return fmt.Errorf("save restrictions to storage: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return restrictions, nil
+}
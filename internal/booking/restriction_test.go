@@ -0,0 +1,172 @@
+package booking_test
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/avstrong/booking/internal/booking"
+)
+
+func TestManager_Restrictions(t *testing.T) {
+	t.Parallel()
+
+	from := startDate()
+
+	//nolint:exhaustruct
+	tests := []struct {
+		name        string
+		restriction booking.RestrictionInput
+		place       booking.Place
+		violations  []booking.RestrictionViolation
+	}{
+		{
+			name:        "stay shorter than min nights of the arrival date",
+			restriction: booking.RestrictionInput{From: from, To: from.AddDate(0, 0, 1), MinNights: 3},
+			place:       luxPlace(from, 2),
+			violations:  []booking.RestrictionViolation{{Date: from, Restriction: booking.RestrictionMinNights, Limit: 3}},
+		},
+		{
+			name:        "min nights of other dates do not apply",
+			restriction: booking.RestrictionInput{From: from.AddDate(0, 0, 1), To: from.AddDate(0, 0, 2), MinNights: 3},
+			place:       luxPlace(from, 2),
+		},
+		{
+			name:        "stay longer than max nights of the arrival date",
+			restriction: booking.RestrictionInput{From: from, To: from.AddDate(0, 0, 1), MaxNights: 1},
+			place:       luxPlace(from, 2),
+			violations:  []booking.RestrictionViolation{{Date: from, Restriction: booking.RestrictionMaxNights, Limit: 1}},
+		},
+		{
+			name:        "arrival on a date closed to arrival",
+			restriction: booking.RestrictionInput{From: from, To: from.AddDate(0, 0, 1), ClosedToArrival: true},
+			place:       luxPlace(from, 2),
+			violations:  []booking.RestrictionViolation{{Date: from, Restriction: booking.RestrictionClosedToArrival}},
+		},
+		{
+			name:        "stay through a date closed to arrival",
+			restriction: booking.RestrictionInput{From: from.AddDate(0, 0, 1), To: from.AddDate(0, 0, 2), ClosedToArrival: true},
+			place:       luxPlace(from, 2),
+		},
+		{
+			name:        "stay including a stop-sell night",
+			restriction: booking.RestrictionInput{From: from.AddDate(0, 0, 1), To: from.AddDate(0, 0, 2), StopSell: true},
+			place:       luxPlace(from, 3),
+			violations:  []booking.RestrictionViolation{{Date: from.AddDate(0, 0, 1), Restriction: booking.RestrictionStopSell}},
+		},
+		{
+			name:        "departure on a stop-sell date",
+			restriction: booking.RestrictionInput{From: from.AddDate(0, 0, 2), To: from.AddDate(0, 0, 3), StopSell: true},
+			place:       luxPlace(from, 2),
+		},
+		{
+			name:        "departure on a date closed to departure",
+			restriction: booking.RestrictionInput{From: from.AddDate(0, 0, 2), To: from.AddDate(0, 0, 3), ClosedToDeparture: true},
+			place:       luxPlace(from, 2),
+			violations:  []booking.RestrictionViolation{{Date: from.AddDate(0, 0, 2), Restriction: booking.RestrictionClosedToDeparture}},
+		},
+		{
+			name:        "arrival on a date closed to departure",
+			restriction: booking.RestrictionInput{From: from, To: from.AddDate(0, 0, 1), ClosedToDeparture: true},
+			place:       luxPlace(from, 2),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			//nolint:exhaustruct
+			manager, _ := newManager(t, booking.Config{IdempotencyKeyTTL: time.Hour}, newHotel(), from)
+
+			tt.restriction.HotelID, tt.restriction.RoomID = "reddison", "lux"
+			if _, err := manager.SetRestrictions(context.Background(), &tt.restriction); err != nil {
+				t.Fatalf("set restrictions: %v", err)
+			}
+
+			for idx := range tt.violations {
+				tt.violations[idx].HotelID, tt.violations[idx].RoomID = "reddison", "lux"
+			}
+
+			//nolint:exhaustruct
+			input := &booking.BookInput{
+				Payer:  booking.Payer{Email: "guest@mail.ru"},
+				Places: []booking.Place{tt.place},
+			}
+
+			quote, err := manager.Quote(context.Background(), input)
+			if err != nil {
+				t.Fatalf("quote: %v", err)
+			}
+
+			if quote.Available != (len(tt.violations) == 0) || !equalViolations(quote.Violations, tt.violations) {
+				t.Errorf("quote is available=%v with %+v, expected %+v", quote.Available, quote.Violations, tt.violations)
+			}
+
+			_, err = manager.CreateOrder(booking.NewContextWithIdempotencyKey(context.Background(), "key"), input)
+
+			if len(tt.violations) == 0 {
+				if err != nil {
+					t.Errorf("create order: %v", err)
+				}
+
+				return
+			}
+
+			restrictionErr := booking.IsRestrictionError(err)
+			if restrictionErr == nil {
+				t.Fatalf("create order got %v, expected restriction error", err)
+			}
+
+			if !equalViolations(restrictionErr.Violations, tt.violations) {
+				t.Errorf("create order violates %+v, expected %+v", restrictionErr.Violations, tt.violations)
+			}
+		})
+	}
+}
+
+func equalViolations(got, want []booking.RestrictionViolation) bool {
+	return slices.EqualFunc(got, want, func(a, b booking.RestrictionViolation) bool {
+		return a.HotelID == b.HotelID && a.RoomID == b.RoomID && a.Date.Equal(b.Date) && a.Restriction == b.Restriction && a.Limit == b.Limit
+	})
+}
+
+func TestManager_CreateOrderReportsUnavailableAndRestrictedNights(t *testing.T) {
+	t.Parallel()
+
+	from := startDate()
+	//nolint:exhaustruct
+	manager, db := newManager(t, booking.Config{IdempotencyKeyTTL: time.Hour}, newHotel(), from)
+
+	//nolint:exhaustruct
+	restriction := &booking.RestrictionInput{
+		HotelID:  "reddison",
+		RoomID:   "lux",
+		From:     from.AddDate(0, 0, 1),
+		To:       from.AddDate(0, 0, 2),
+		StopSell: true,
+	}
+	if _, err := manager.SetRestrictions(context.Background(), restriction); err != nil {
+		t.Fatalf("set restrictions: %v", err)
+	}
+
+	// The second night is on stop-sell and the last one is not on sale at all.
+	//nolint:exhaustruct
+	_, err := manager.CreateOrder(booking.NewContextWithIdempotencyKey(context.Background(), "key"), &booking.BookInput{
+		Payer:  booking.Payer{Email: "guest@mail.ru"},
+		Places: []booking.Place{luxPlace(from, nights+1)},
+	})
+
+	if availabilityErr := booking.IsAvailabilityError(err); availabilityErr == nil || availabilityErr.UnavailableRoomsCount() != 1 {
+		t.Errorf("create order got %v, expected availability error of the last night", err)
+	}
+
+	if restrictionErr := booking.IsRestrictionError(err); restrictionErr == nil || len(restrictionErr.Violations) != 1 {
+		t.Errorf("create order got %v, expected restriction error of the stop-sell night", err)
+	}
+
+	if got := quotas(t, db, from); !slices.Equal(got, []int{quota, quota, quota}) {
+		t.Errorf("quotas are %v after a refused order, expected them untouched", got)
+	}
+}
@@ -0,0 +1,263 @@
+package booking
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/avstrong/booking/internal/money"
+)
+
+// maxSearchNights limits the date range of an availability search.
+const maxSearchNights = 90
+
+// AvailabilitySearch asks which rooms of the hotels can be booked for nights in [From, To).
+type AvailabilitySearch struct {
+	HotelIDs []string
+	// RoomIDs limit the search to some rooms. Empty means every room of the hotels.
+	RoomIDs []string
+	From    time.Time
+	To      time.Time
+	// Guests must fit into one room: rooms with a smaller max occupancy are not returned.
+	Guests int
+}
+
+// AvailabilityFilter selects availabilities of rooms for nights in [From, To).
+type AvailabilityFilter struct {
+	HotelIDs []string
+	RoomIDs  []string
+	From     time.Time
+	To       time.Time
+}
+
+// NightOffer is the quota and the price of a room for one night. Price is nil when the night has no rate.
+type NightOffer struct {
+	Date  time.Time    `json:"date"`
+	Quota int          `json:"quota"`
+	Price *money.Money `json:"price,omitempty"`
+}
+
+// RoomOffer is a room of a hotel for the searched stay. The room is bookable when every night has quota
+// and a price and no stay restriction forbids the stay, and then Price is the price of the whole stay.
+type RoomOffer struct {
+	HotelID    string                 `json:"hotel_id"`
+	RoomID     string                 `json:"room_id"`
+	Name       string                 `json:"name"`
+	Nights     []NightOffer           `json:"nights"`
+	Violations []RestrictionViolation `json:"violations,omitempty"`
+	Bookable   bool                   `json:"bookable"`
+	Price      *money.Money           `json:"price,omitempty"`
+}
+
+type AvailabilitySearchResult struct {
+	Rooms []*RoomOffer `json:"rooms"`
+}
+
+func (s *AvailabilitySearch) validate(hotels map[string]*Hotel) error {
+	inputErr := NewInputError()
+
+	if len(s.HotelIDs) == 0 {
+		inputErr.AddError("hotel_id", "provide at least one hotel_id")
+	}
+
+	switch {
+	case s.From.IsZero() || s.To.IsZero():
+		inputErr.AddError("from", "provide from and to dates")
+	case !s.To.After(s.From):
+		inputErr.AddError("to", "to must be at least one night after from")
+	case s.To.Sub(s.From) > maxSearchNights*24*time.Hour:
+		inputErr.AddError("to", fmt.Sprintf("search at most %v nights", maxSearchNights))
+	}
+
+	for _, id := range s.HotelIDs {
+		hotel, ok := hotels[id]
+
+		switch {
+		case !ok:
+			inputErr.AddError("hotel_id", fmt.Sprintf("hotel '%v' does not exist", id))
+		case !hotel.Active:
+			inputErr.AddError("hotel_id", fmt.Sprintf("hotel '%v' is not active", id))
+		case !s.From.IsZero() && hotel.date(s.From).Before(hotel.today()):
+			inputErr.AddError("from", fmt.Sprintf("from must not be in the past in hotel '%v'", id))
+		}
+	}
+
+	if s.Guests < 1 {
+		inputErr.AddError("guests", "guests must be at least 1")
+	}
+
+	if inputErr.FieldsCount() > 0 {
+		return inputErr
+	}
+
+	return nil
+}
+
+// SearchAvailability returns nightly quota and prices of rooms of the hotels. Unlike booking, nights
+// which can not be sold do not fail the search: they are reported and make the room not bookable.
+func (m *Manager) SearchAvailability(ctx context.Context, search *AvailabilitySearch) (*AvailabilitySearchResult, error) {
+	places := make([]Place, 0, len(search.HotelIDs))
+	for _, id := range search.HotelIDs {
+		//nolint:exhaustruct // only hotels are needed
+		places = append(places, Place{HotelID: id})
+	}
+
+	hotels, err := m.getHotels(ctx, places)
+	if err != nil {
+		return nil, fmt.Errorf("get hotels: %w", err)
+	}
+
+	if err := search.validate(hotels); err != nil {
+		return nil, err
+	}
+
+	result := &AvailabilitySearchResult{Rooms: []*RoomOffer{}}
+	searched := make(map[string]bool, len(search.HotelIDs))
+
+	for _, hotelID := range search.HotelIDs {
+		if searched[hotelID] {
+			continue
+		}
+
+		searched[hotelID] = true
+
+		offers, err := m.searchHotel(ctx, hotels[hotelID], search)
+		if err != nil {
+			return nil, err
+		}
+
+		result.Rooms = append(result.Rooms, offers...)
+	}
+
+	return result, nil
+}
+
+// searchHotel builds offers of the hotel's rooms for the stay in the hotel's local dates.
+func (m *Manager) searchHotel(ctx context.Context, hotel *Hotel, search *AvailabilitySearch) ([]*RoomOffer, error) {
+	from, to := hotel.date(search.From), hotel.date(search.To)
+
+	availabilities, err := m.storage.SearchAvailabilities(ctx, AvailabilityFilter{
+		HotelIDs: []string{hotel.ID},
+		RoomIDs:  search.RoomIDs,
+		From:     from,
+		To:       to,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("search availabilities in storage: %w", err)
+	}
+
+	// Rooms are the active ones of the catalog which have inventory for the dates and fit the guests.
+	quotas := make(map[string]int, len(availabilities))
+	rooms := make(map[string]struct{})
+	roomIDs := make([]string, 0)
+
+	for _, availability := range availabilities {
+		room := hotel.room(availability.RoomID)
+		if room == nil || !room.Active || room.MaxOccupancy < search.Guests {
+			continue
+		}
+
+		if _, ok := rooms[availability.RoomID]; !ok {
+			rooms[availability.RoomID] = struct{}{}
+			roomIDs = append(roomIDs, availability.RoomID)
+		}
+
+		quotas[availabilityKey(availability.HotelID, availability.RoomID, availability.Date)] = availability.Quota
+	}
+
+	sort.Strings(roomIDs)
+
+	inputs := make([]GetAvailabilityInput, 0, len(roomIDs))
+	stays := make([]Place, 0, len(roomIDs))
+
+	for _, roomID := range roomIDs {
+		inputs = append(inputs, GetAvailabilityInput{HotelID: hotel.ID, RoomID: roomID, From: from, To: to})
+		//nolint:exhaustruct // only nights of the stay are needed
+		stays = append(stays, Place{HotelID: hotel.ID, RoomID: roomID, From: from, To: to})
+	}
+
+	rates, err := m.storage.GetRates(ctx, inputs)
+	if err != nil {
+		return nil, fmt.Errorf("get rates from storage: %w", err)
+	}
+
+	// Nights are priced for the searched guests staying in one room.
+	prices := make(map[string]money.Money, len(rates))
+
+	for _, rate := range rates {
+		price, err := rate.priceFor(search.Guests)
+		if err != nil {
+			return nil, err
+		}
+
+		prices[availabilityKey(rate.HotelID, rate.RoomID, rate.Date)] = price
+	}
+
+	restrictions, err := m.getRestrictions(ctx, stays)
+	if err != nil {
+		return nil, err
+	}
+
+	offers := make([]*RoomOffer, 0, len(roomIDs))
+
+	for idx, roomID := range roomIDs {
+		offer, err := newRoomOffer(hotel.ID, roomID, from, to, quotas, prices)
+		if err != nil {
+			return nil, err
+		}
+
+		offer.Name = hotel.room(roomID).Name
+
+		if offer.Violations = stays[idx].restrictionViolations(restrictions); len(offer.Violations) > 0 {
+			offer.Bookable = false
+			offer.Price = nil
+		}
+
+		offers = append(offers, offer)
+	}
+
+	return offers, nil
+}
+
+func newRoomOffer(hotelID, roomID string, from, to time.Time, quotas map[string]int, prices map[string]money.Money) (*RoomOffer, error) {
+	//nolint:exhaustruct // price is set for bookable rooms only
+	offer := &RoomOffer{
+		HotelID:  hotelID,
+		RoomID:   roomID,
+		Bookable: true,
+	}
+
+	var nightPrices []money.Money
+
+	for d := from; d.Before(to); d = d.AddDate(0, 0, 1) {
+		key := availabilityKey(hotelID, roomID, d)
+
+		//nolint:exhaustruct // price is set for priced nights only
+		night := NightOffer{Date: d, Quota: quotas[key]}
+
+		if price, ok := prices[key]; ok {
+			night.Price = &price
+			nightPrices = append(nightPrices, price)
+		} else {
+			offer.Bookable = false
+		}
+
+		if night.Quota < 1 {
+			offer.Bookable = false
+		}
+
+		offer.Nights = append(offer.Nights, night)
+	}
+
+	if offer.Bookable {
+		price, err := money.Sum(nightPrices...)
+		if err != nil {
+			return nil, fmt.Errorf("sum up prices of room %v in hotel %v: %w", roomID, hotelID, err)
+		}
+
+		offer.Price = &price
+	}
+
+	return offer, nil
+}
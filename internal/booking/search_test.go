@@ -0,0 +1,308 @@
+package booking_test
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/avstrong/booking/internal/booking"
+	"github.com/avstrong/booking/internal/money"
+	"github.com/avstrong/booking/internal/storage/memory"
+)
+
+// saveRoom adds the room type to the "reddison" hotel and puts it on sale at 3000 RUB with the quota of every night
+// from the date.
+func saveRoom(t *testing.T, db *memory.DB, room *booking.RoomType, from time.Time, quotas []int) {
+	t.Helper()
+
+	room.HotelID = "reddison"
+	roomID := room.ID
+
+	ctx, err := db.BeginTransaction(context.Background(), "")
+	if err != nil {
+		t.Fatalf("begin transaction: %v", err)
+	}
+
+	availabilities := make([]*booking.RoomAvailability, 0, len(quotas))
+	rates := make([]*booking.RoomRate, 0, len(quotas))
+
+	for i, quota := range quotas {
+		availabilities = append(availabilities, &booking.RoomAvailability{
+			HotelID: "reddison",
+			RoomID:  roomID,
+			Date:    from.AddDate(0, 0, i),
+			Quota:   quota,
+		})
+		//nolint:exhaustruct
+		rates = append(rates, &booking.RoomRate{
+			HotelID: "reddison",
+			RoomID:  roomID,
+			Date:    from.AddDate(0, 0, i),
+			Price:   money.FromMajor(3000, "RUB"),
+		})
+	}
+
+	if err := db.SaveRoomAvailabilities(ctx, availabilities); err != nil {
+		t.Fatalf("save room availabilities: %v", err)
+	}
+
+	if err := db.SaveRates(ctx, rates); err != nil {
+		t.Fatalf("save rates: %v", err)
+	}
+
+	if err := db.SaveRoomTypes(ctx, []*booking.RoomType{room}); err != nil {
+		t.Fatalf("save room types: %v", err)
+	}
+
+	if err := db.CommitTransaction(ctx); err != nil {
+		t.Fatalf("commit transaction: %v", err)
+	}
+}
+
+// offer sums up a room offer: quota of its nights and the price if the room is bookable.
+type offer struct {
+	roomID string
+	quotas []int
+	price  string
+}
+
+func offers(result *booking.AvailabilitySearchResult) []offer {
+	summary := make([]offer, 0, len(result.Rooms))
+
+	for _, room := range result.Rooms {
+		//nolint:exhaustruct
+		o := offer{roomID: room.RoomID}
+
+		for _, night := range room.Nights {
+			o.quotas = append(o.quotas, night.Quota)
+		}
+
+		if room.Bookable && room.Price != nil {
+			o.price = room.Price.String()
+		}
+
+		summary = append(summary, o)
+	}
+
+	return summary
+}
+
+func TestManager_SearchAvailability(t *testing.T) {
+	t.Parallel()
+
+	from := startDate()
+	//nolint:exhaustruct
+	manager, db := newManager(t, booking.Config{}, newHotel(), from)
+	// The second night of the standard room is sold out.
+	//nolint:exhaustruct
+	saveRoom(t, db, &booking.RoomType{ID: "std", Name: "Standard", MaxOccupancy: 2, Active: true}, from, []int{2, 0, 2})
+	// Rooms which are off sale are never found.
+	//nolint:exhaustruct
+	saveRoom(t, db, &booking.RoomType{ID: "old", Name: "Old", MaxOccupancy: 2, Active: false}, from, []int{2, 2, 2})
+	createOrder(t, manager, "key", luxPlace(from, 1))
+
+	//nolint:exhaustruct
+	tests := []struct {
+		name   string
+		search booking.AvailabilitySearch
+		want   []offer
+	}{
+		{
+			name:   "every room",
+			search: booking.AvailabilitySearch{From: from, To: from.AddDate(0, 0, nights)},
+			want: []offer{
+				{roomID: "lux", quotas: []int{quota - 1, quota, quota}, price: "15000.00 RUB"},
+				{roomID: "std", quotas: []int{2, 0, 2}},
+			},
+		},
+		{
+			name:   "room",
+			search: booking.AvailabilitySearch{RoomIDs: []string{"std"}, From: from, To: from.AddDate(0, 0, 1)},
+			want:   []offer{{roomID: "std", quotas: []int{2}, price: "3000.00 RUB"}},
+		},
+		{
+			name:   "dates",
+			search: booking.AvailabilitySearch{From: from.AddDate(0, 0, 1), To: from.AddDate(0, 0, 2)},
+			want: []offer{
+				{roomID: "lux", quotas: []int{quota}, price: "5000.00 RUB"},
+				{roomID: "std", quotas: []int{0}},
+			},
+		},
+		{
+			name:   "nights after the sale",
+			search: booking.AvailabilitySearch{RoomIDs: []string{"lux"}, From: from.AddDate(0, 0, 2), To: from.AddDate(0, 0, 4)},
+			want:   []offer{{roomID: "lux", quotas: []int{quota, 0}}},
+		},
+		{
+			name:   "one guest",
+			search: booking.AvailabilitySearch{From: from, To: from.AddDate(0, 0, 1), Guests: 1},
+			// The standard room has no single price, so one guest pays for the room.
+			want: []offer{
+				{roomID: "lux", quotas: []int{quota - 1}, price: "4500.00 RUB"},
+				{roomID: "std", quotas: []int{2}, price: "3000.00 RUB"},
+			},
+		},
+		{
+			name:   "rooms for the guests",
+			search: booking.AvailabilitySearch{From: from, To: from.AddDate(0, 0, 1), Guests: 3},
+			// Two guests and an extra bed.
+			want: []offer{{roomID: "lux", quotas: []int{quota - 1}, price: "6500.00 RUB"}},
+		},
+		{
+			name:   "inactive room",
+			search: booking.AvailabilitySearch{RoomIDs: []string{"old"}, From: from, To: from.AddDate(0, 0, 1)},
+			want:   []offer{},
+		},
+		{
+			name:   "no inventory",
+			search: booking.AvailabilitySearch{From: from.AddDate(0, 0, 10), To: from.AddDate(0, 0, 11)},
+			want:   []offer{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			tt.search.HotelIDs = []string{"reddison"}
+			if tt.search.Guests == 0 {
+				tt.search.Guests = 2
+			}
+
+			result, err := manager.SearchAvailability(context.Background(), &tt.search)
+			if err != nil {
+				t.Fatalf("search availability: %v", err)
+			}
+
+			if got := offers(result); !slices.EqualFunc(got, tt.want, func(a, b offer) bool {
+				return a.roomID == b.roomID && slices.Equal(a.quotas, b.quotas) && a.price == b.price
+			}) {
+				t.Errorf("found %+v, expected %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestManager_SearchAvailabilityRejectsInvalidSearch(t *testing.T) {
+	t.Parallel()
+
+	from := startDate()
+	hotels := []string{"reddison"}
+	unknown := []string{"other"}
+	inactive := []string{"closed"}
+
+	//nolint:exhaustruct
+	tests := []struct {
+		name   string
+		search booking.AvailabilitySearch
+	}{
+		{name: "no hotel", search: booking.AvailabilitySearch{From: from, To: from.AddDate(0, 0, 1), Guests: 1}},
+		{name: "no dates", search: booking.AvailabilitySearch{HotelIDs: hotels, Guests: 1}},
+		{name: "to before from", search: booking.AvailabilitySearch{HotelIDs: hotels, From: from, To: from, Guests: 1}},
+		{name: "too long", search: booking.AvailabilitySearch{HotelIDs: hotels, From: from, To: from.AddDate(0, 0, 91), Guests: 1}},
+		{name: "past", search: booking.AvailabilitySearch{
+			HotelIDs: hotels, From: time.Now().UTC().AddDate(0, 0, -2), To: from, Guests: 1,
+		}},
+		{name: "no guests", search: booking.AvailabilitySearch{HotelIDs: hotels, From: from, To: from.AddDate(0, 0, 1)}},
+		{name: "unknown hotel", search: booking.AvailabilitySearch{HotelIDs: unknown, From: from, To: from.AddDate(0, 0, 1), Guests: 1}},
+		{name: "inactive hotel", search: booking.AvailabilitySearch{HotelIDs: inactive, From: from, To: from.AddDate(0, 0, 1), Guests: 1}},
+	}
+
+	//nolint:exhaustruct
+	manager, db := newManager(t, booking.Config{}, newHotel(), from)
+	//nolint:exhaustruct
+	saveHotel(t, db, &booking.Hotel{ID: "closed", Name: "Closed", TimeZone: "UTC", Active: false})
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if _, err := manager.SearchAvailability(context.Background(), &tt.search); booking.IsInputError(err) == nil {
+				t.Errorf("search got %v, expected input error", err)
+			}
+		})
+	}
+}
+
+func TestManager_SearchAvailabilityAppliesRestrictions(t *testing.T) {
+	t.Parallel()
+
+	from := startDate()
+
+	//nolint:exhaustruct
+	tests := []struct {
+		name        string
+		restriction booking.RestrictionInput
+		violations  []booking.RestrictionType
+	}{
+		{
+			name:        "stop-sell night",
+			restriction: booking.RestrictionInput{From: from.AddDate(0, 0, 1), To: from.AddDate(0, 0, 2), StopSell: true},
+			violations:  []booking.RestrictionType{booking.RestrictionStopSell},
+		},
+		{
+			name:        "arrival closed and stay too short",
+			restriction: booking.RestrictionInput{From: from, To: from.AddDate(0, 0, 1), ClosedToArrival: true, MinNights: 3},
+			violations:  []booking.RestrictionType{booking.RestrictionClosedToArrival, booking.RestrictionMinNights},
+		},
+		{
+			name:        "departure closed",
+			restriction: booking.RestrictionInput{From: from.AddDate(0, 0, 2), To: from.AddDate(0, 0, 3), ClosedToDeparture: true},
+			violations:  []booking.RestrictionType{booking.RestrictionClosedToDeparture},
+		},
+		{
+			name:        "stop-sell on the departure date",
+			restriction: booking.RestrictionInput{From: from.AddDate(0, 0, 2), To: from.AddDate(0, 0, 3), StopSell: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			//nolint:exhaustruct
+			manager, _ := newManager(t, booking.Config{}, newHotel(), from)
+
+			tt.restriction.HotelID, tt.restriction.RoomID = "reddison", "lux"
+			if _, err := manager.SetRestrictions(context.Background(), &tt.restriction); err != nil {
+				t.Fatalf("set restrictions: %v", err)
+			}
+
+			result, err := manager.SearchAvailability(context.Background(), &booking.AvailabilitySearch{
+				HotelIDs: []string{"reddison"},
+				RoomIDs:  []string{"lux"},
+				From:     from,
+				To:       from.AddDate(0, 0, 2),
+				Guests:   1,
+			})
+			if err != nil {
+				t.Fatalf("search availability: %v", err)
+			}
+
+			if len(result.Rooms) != 1 {
+				t.Fatalf("found rooms %+v, expected lux", result.Rooms)
+			}
+
+			room := result.Rooms[0]
+
+			var got []booking.RestrictionType
+			for _, violation := range room.Violations {
+				got = append(got, violation.Restriction)
+			}
+
+			if !slices.Equal(got, tt.violations) {
+				t.Errorf("room violates %v, expected %v", got, tt.violations)
+			}
+
+			// Restricted rooms still show their quota, but can not be booked.
+			if bookable := len(tt.violations) == 0; room.Bookable != bookable || (room.Price != nil) != bookable {
+				t.Errorf("room is bookable=%v at %v, expected bookable=%v", room.Bookable, room.Price, bookable)
+			}
+
+			if len(room.Nights) != 2 || room.Nights[0].Quota != quota {
+				t.Errorf("room has nights %+v, expected both nights with their quota", room.Nights)
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package booking
+
+type OrderStatus string
+
+const (
+	OrderStatusHeld      OrderStatus = "held"
+	OrderStatusExpired   OrderStatus = "expired"
+	OrderStatusPending   OrderStatus = "pending"
+	OrderStatusConfirmed OrderStatus = "confirmed"
+	OrderStatusCancelled OrderStatus = "cancelled"
+	OrderStatusCheckedIn OrderStatus = "checked_in"
+	OrderStatusCompleted OrderStatus = "completed"
+	OrderStatusNoShow    OrderStatus = "no_show"
+)
+
+func (s OrderStatus) valid() bool {
+	switch s {
+	case OrderStatusHeld,
+		OrderStatusExpired,
+		OrderStatusPending,
+		OrderStatusConfirmed,
+		OrderStatusCancelled,
+		OrderStatusCheckedIn,
+		OrderStatusCompleted,
+		OrderStatusNoShow:
+		return true
+	}
+
+	return false
+}
+
+// releasesInventory reports whether moving an order to the status returns its quota to inventory.
+func (s OrderStatus) releasesInventory() bool {
+	return s == OrderStatusCancelled || s == OrderStatusExpired
+}
+
+// canTransitionTo describes the order lifecycle:
+//
+//	held -> confirmed | cancelled | expired
+//	pending -> confirmed | cancelled
+//	confirmed -> cancelled | checked_in | no_show
+//	checked_in -> completed
+//
+// Cancelled, expired, completed and no-show orders are final. A held order expires only after its hold time is over.
+func (s OrderStatus) canTransitionTo(to OrderStatus) bool {
+	switch s {
+	case OrderStatusHeld:
+		return to == OrderStatusConfirmed || to == OrderStatusCancelled || to == OrderStatusExpired
+	case OrderStatusPending:
+		return to == OrderStatusConfirmed || to == OrderStatusCancelled
+	case OrderStatusConfirmed:
+		return to == OrderStatusCancelled || to == OrderStatusCheckedIn || to == OrderStatusNoShow
+	case OrderStatusCheckedIn:
+		return to == OrderStatusCompleted
+	case OrderStatusCancelled, OrderStatusExpired, OrderStatusCompleted, OrderStatusNoShow:
+		return false
+	}
+
+	return false
+}
+
+func (o *Order) transition(to OrderStatus) (OrderStatus, error) {
+	if !o.Status.canTransitionTo(to) {
+		return o.Status, &TransitionError{From: o.Status, To: to}
+	}
+
+	from := o.Status
+	o.Status = to
+
+	return from, nil
+}
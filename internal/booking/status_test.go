@@ -0,0 +1,115 @@
+package booking_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/avstrong/booking/internal/booking"
+)
+
+func TestManager_ChangeOrderStatus(t *testing.T) {
+	t.Parallel()
+
+	confirmed := []booking.OrderStatus{booking.OrderStatusConfirmed}
+	checkedIn := []booking.OrderStatus{booking.OrderStatusConfirmed, booking.OrderStatusCheckedIn}
+
+	tests := []struct {
+		name string
+		// path moves a new order to the status the transition starts from.
+		path    []booking.OrderStatus
+		to      booking.OrderStatus
+		allowed bool
+	}{
+		{name: "pending to confirmed", to: booking.OrderStatusConfirmed, allowed: true},
+		{name: "pending to cancelled", to: booking.OrderStatusCancelled, allowed: true},
+		{name: "pending to checked in", to: booking.OrderStatusCheckedIn, allowed: false},
+		{name: "pending to no-show", to: booking.OrderStatusNoShow, allowed: false},
+		{name: "confirmed to checked in", path: confirmed, to: booking.OrderStatusCheckedIn, allowed: true},
+		{name: "confirmed to no-show", path: confirmed, to: booking.OrderStatusNoShow, allowed: true},
+		{name: "confirmed to cancelled", path: confirmed, to: booking.OrderStatusCancelled, allowed: true},
+		{name: "confirmed to completed", path: confirmed, to: booking.OrderStatusCompleted, allowed: false},
+		{name: "confirmed to pending", path: confirmed, to: booking.OrderStatusPending, allowed: false},
+		{name: "checked in to completed", path: checkedIn, to: booking.OrderStatusCompleted, allowed: true},
+		{name: "checked in to cancelled", path: checkedIn, to: booking.OrderStatusCancelled, allowed: false},
+		{name: "cancelled is final", path: []booking.OrderStatus{booking.OrderStatusCancelled}, to: booking.OrderStatusConfirmed, allowed: false},
+		{
+			name:    "no-show is final",
+			path:    append(confirmed, booking.OrderStatusNoShow),
+			to:      booking.OrderStatusCheckedIn,
+			allowed: false,
+		},
+		{
+			name:    "completed is final",
+			path:    append(checkedIn, booking.OrderStatusCompleted),
+			to:      booking.OrderStatusCancelled,
+			allowed: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			from := startDate()
+			//nolint:exhaustruct
+			manager, db := newManager(t, booking.Config{}, newHotel(), from)
+
+			order := createOrder(t, manager, "key", luxPlace(from, 1))
+			if order.Status != booking.OrderStatusPending {
+				t.Fatalf("new order is %v, expected %v", order.Status, booking.OrderStatusPending)
+			}
+
+			for _, status := range tt.path {
+				if _, err := manager.ChangeOrderStatus(context.Background(), order.ID, status); err != nil {
+					t.Fatalf("change status to %v: %v", status, err)
+				}
+			}
+
+			changed, err := manager.ChangeOrderStatus(context.Background(), order.ID, tt.to)
+
+			if tt.allowed {
+				if err != nil {
+					t.Fatalf("change status to %v: %v", tt.to, err)
+				}
+
+				if changed.Status != tt.to {
+					t.Errorf("order is %v, expected %v", changed.Status, tt.to)
+				}
+
+				return
+			}
+
+			// Transition errors are answered with 409 Conflict.
+			transitionErr := booking.IsTransitionError(err)
+			if transitionErr == nil {
+				t.Fatalf("change status to %v got %v, expected transition error", tt.to, err)
+			}
+
+			if transitionErr.To != tt.to {
+				t.Errorf("transition error is to %v, expected %v", transitionErr.To, tt.to)
+			}
+
+			stored, err := db.GetOrder(context.Background(), order.ID)
+			if err != nil {
+				t.Fatalf("get order: %v", err)
+			}
+
+			if stored.Status == tt.to {
+				t.Errorf("refused transition changed the order to %v", stored.Status)
+			}
+		})
+	}
+}
+
+func TestManager_ChangeOrderStatusRejectsUnknownStatus(t *testing.T) {
+	t.Parallel()
+
+	from := startDate()
+	//nolint:exhaustruct
+	manager, _ := newManager(t, booking.Config{}, newHotel(), from)
+	order := createOrder(t, manager, "key", luxPlace(from, 1))
+
+	if _, err := manager.ChangeOrderStatus(context.Background(), order.ID, "lost"); booking.IsInputError(err) == nil {
+		t.Errorf("unknown status got %v, expected input error", err)
+	}
+}
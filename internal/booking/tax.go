@@ -0,0 +1,185 @@
+package booking
+
+import (
+	"fmt"
+
+	"github.com/avstrong/booking/internal/money"
+)
+
+type TaxKind string
+
+const (
+	// TaxKindPercentage is a percentage of the room price after discounts, e.g. VAT.
+	TaxKindPercentage TaxKind = "percentage"
+	// TaxKindPerNight is a fixed amount for every booked room night, e.g. a cleaning fee.
+	TaxKindPerNight TaxKind = "per_night"
+	// TaxKindPerGuest is a fixed amount for every guest and night, e.g. a tourist tax.
+	TaxKindPerGuest TaxKind = "per_guest"
+)
+
+// TaxRule is a tax or a fee which a hotel charges for a stay.
+type TaxRule struct {
+	// Code names the rule on line items, e.g. "vat" or "tourist_tax".
+	Code string  `json:"code"`
+	Kind TaxKind `json:"kind"`
+	// Fee rules are charges for services and are reported as fee line items rather than taxes.
+	Fee        bool        `json:"fee"`
+	Percentage float64     `json:"percentage,omitempty"`
+	Amount     money.Money `json:"amount"`
+	// Inclusive percentage rules are already part of nightly rates, like VAT in Russian prices.
+	// They are reported on the order but do not change its price.
+	Inclusive bool `json:"inclusive"`
+}
+
+func (r *TaxRule) validate() error {
+	if r.Code == "" {
+		return fmt.Errorf("code is empty: %w", ErrInvalidTaxRule)
+	}
+
+	switch r.Kind {
+	case TaxKindPercentage:
+		if r.Percentage <= 0 {
+			return fmt.Errorf("rule %v: percentage must be positive: %w", r.Code, ErrInvalidTaxRule)
+		}
+	case TaxKindPerNight, TaxKindPerGuest:
+		if r.Amount.Amount <= 0 || !r.Amount.Currency.Valid() {
+			return fmt.Errorf("rule %v: amount must be positive and in a known currency: %w", r.Code, ErrInvalidTaxRule)
+		}
+
+		if r.Inclusive {
+			return fmt.Errorf("rule %v: only percentage rules can be inclusive: %w", r.Code, ErrInvalidTaxRule)
+		}
+	default:
+		return fmt.Errorf("rule %v: unknown kind '%v': %w", r.Code, r.Kind, ErrInvalidTaxRule)
+	}
+
+	return nil
+}
+
+func (r *TaxRule) itemType() LineItemType {
+	if r.Fee {
+		return LineItemFee
+	}
+
+	return LineItemTax
+}
+
+// amount calculates the rule for a hotel stay.
+func (r *TaxRule) amount(stay *hotelStay) money.Money {
+	switch r.Kind {
+	case TaxKindPercentage:
+		if r.Inclusive {
+			return stay.taxable.IncludedPercent(r.Percentage)
+		}
+
+		return stay.taxable.Percent(r.Percentage)
+	case TaxKindPerNight:
+		return r.Amount.Mul(stay.nights)
+	case TaxKindPerGuest:
+		return r.Amount.Mul(stay.guestNights)
+	default:
+		return money.New(0, r.Amount.Currency)
+	}
+}
+
+// guests returns how many guests stay in the place. Places booked before occupancy was recorded have one guest.
+func (p *Place) guests() int64 {
+	return int64(max(p.Occupancy.Guests(), 1))
+}
+
+// hotelStay sums up the part of an order in one hotel.
+type hotelStay struct {
+	nights      int64
+	guestNights int64
+	// taxable is the room price after discounts.
+	taxable money.Money
+}
+
+// hotelStays splits the order by hotels in order of their first places. Discounts of the whole order
+// are shared between hotels in proportion to their discounted room prices.
+func (o *Order) hotelStays() ([]string, map[string]*hotelStay, error) {
+	var ids []string
+
+	stays := make(map[string]*hotelStay)
+	zero := money.New(0, o.Price.Currency)
+
+	for _, place := range o.Places {
+		stay, ok := stays[place.HotelID]
+		if !ok {
+			stay = &hotelStay{nights: 0, guestNights: 0, taxable: zero}
+			stays[place.HotelID] = stay
+			ids = append(ids, place.HotelID)
+		}
+
+		stay.nights += int64(place.Nights())
+		stay.guestNights += int64(place.Nights()) * place.guests()
+	}
+
+	orderDiscount := zero
+
+	var err error
+
+	for _, item := range o.LineItems {
+		switch {
+		case item.Type == LineItemNight || item.Type == LineItemDiscount && item.HotelID != "":
+			stays[item.HotelID].taxable, err = stays[item.HotelID].taxable.Add(item.Amount)
+		case item.Type == LineItemDiscount || item.Type == LineItemDiscountCap:
+			orderDiscount, err = orderDiscount.Add(item.Amount)
+		}
+
+		if err != nil {
+			return nil, nil, fmt.Errorf("sum up line items: %w", err)
+		}
+	}
+
+	var rooms int64
+	for _, stay := range stays {
+		rooms += stay.taxable.Amount
+	}
+
+	for _, stay := range stays {
+		share := orderDiscount.Ratio(stay.taxable.Amount, rooms)
+
+		if stay.taxable, err = stay.taxable.Add(share); err != nil {
+			return nil, nil, fmt.Errorf("share order discount: %w", err)
+		}
+
+		if stay.taxable.IsNegative() {
+			stay.taxable = zero
+		}
+	}
+
+	return ids, stays, nil
+}
+
+// applyTaxes adds taxes and fees of the hotels to the priced order.
+func (o *Order) applyTaxes(hotels map[string]*Hotel) error {
+	ids, stays, err := o.hotelStays()
+	if err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		hotel, ok := hotels[id]
+		if !ok {
+			return fmt.Errorf("hotel %v of the order is not loaded: %w", id, ErrLogic)
+		}
+
+		for _, rule := range hotel.Taxes {
+			//nolint:exhaustruct // taxes are not bound to nights and strategies
+			o.LineItems = append(o.LineItems, LineItem{
+				Type:     rule.itemType(),
+				HotelID:  id,
+				TaxCode:  rule.Code,
+				Included: rule.Inclusive,
+				Amount:   rule.amount(stays[id]),
+			})
+		}
+	}
+
+	if err := o.total(); err != nil {
+		return fmt.Errorf("total order with taxes: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,115 @@
+package booking_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/avstrong/booking/internal/booking"
+	"github.com/avstrong/booking/internal/money"
+)
+
+func TestManager_CreateOrderAppliesTaxes(t *testing.T) {
+	t.Parallel()
+
+	hotel := newHotel()
+	hotel.Taxes = []booking.TaxRule{
+		{Code: "vat", Kind: booking.TaxKindPercentage, Percentage: 20, Inclusive: true},
+		{Code: "city_tax", Kind: booking.TaxKindPercentage, Percentage: 5},
+		{Code: "tourist_tax", Kind: booking.TaxKindPerGuest, Amount: money.FromMajor(100, "RUB")},
+		{Code: "cleaning", Kind: booking.TaxKindPerNight, Fee: true, Amount: money.FromMajor(300, "RUB")},
+	}
+
+	from := startDate()
+	//nolint:exhaustruct
+	manager, _ := newManager(t, booking.Config{}, hotel, from)
+
+	order := createOrder(t, manager, "key", luxPlace(from, 2))
+
+	// Two nights at 5000 RUB are 10000 RUB.
+	tests := []struct {
+		code     string
+		itemType booking.LineItemType
+		included bool
+		want     money.Money
+	}{
+		// 20% included in 10000 RUB.
+		{code: "vat", itemType: booking.LineItemTax, included: true, want: money.New(166667, "RUB")},
+		{code: "city_tax", itemType: booking.LineItemTax, included: false, want: money.FromMajor(500, "RUB")},
+		// 100 RUB for two guests and 2 nights.
+		{code: "tourist_tax", itemType: booking.LineItemTax, included: false, want: money.FromMajor(400, "RUB")},
+		{code: "cleaning", itemType: booking.LineItemFee, included: false, want: money.FromMajor(600, "RUB")},
+	}
+
+	items := make(map[string]booking.LineItem)
+
+	for _, item := range order.LineItems {
+		if item.TaxCode != "" {
+			items[item.TaxCode] = item
+		}
+	}
+
+	for _, tt := range tests {
+		item, ok := items[tt.code]
+		if !ok {
+			t.Errorf("no line item for %v", tt.code)
+
+			continue
+		}
+
+		if item.Type != tt.itemType || item.Included != tt.included || item.Amount != tt.want || item.HotelID != hotel.ID {
+			t.Errorf("line item for %v is %+v, expected %v %v included=%v", tt.code, item, tt.itemType, tt.want, tt.included)
+		}
+	}
+
+	// Included VAT does not change the price.
+	if want := money.FromMajor(11500, "RUB"); order.Price != want {
+		t.Errorf("order price is %v, expected %v", order.Price, want)
+	}
+}
+
+func TestManager_CreateOrderRejectsInvalidTaxes(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		rule booking.TaxRule
+	}{
+		//nolint:exhaustruct
+		{name: "no code", rule: booking.TaxRule{Kind: booking.TaxKindPercentage, Percentage: 20}},
+		//nolint:exhaustruct
+		{name: "unknown kind", rule: booking.TaxRule{Code: "vat", Kind: "flat"}},
+		//nolint:exhaustruct
+		{name: "zero percentage", rule: booking.TaxRule{Code: "vat", Kind: booking.TaxKindPercentage}},
+		//nolint:exhaustruct
+		{name: "zero amount", rule: booking.TaxRule{Code: "cleaning", Kind: booking.TaxKindPerNight, Amount: money.New(0, "RUB")}},
+		//nolint:exhaustruct
+		{name: "unknown currency", rule: booking.TaxRule{Code: "cleaning", Kind: booking.TaxKindPerNight, Amount: money.New(100, "XXX")}},
+		//nolint:exhaustruct
+		{name: "inclusive amount", rule: booking.TaxRule{
+			Code: "tourist_tax", Kind: booking.TaxKindPerGuest, Amount: money.FromMajor(100, "RUB"), Inclusive: true,
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			hotel := newHotel()
+			hotel.Taxes = []booking.TaxRule{tt.rule}
+
+			from := startDate()
+			//nolint:exhaustruct
+			manager, _ := newManager(t, booking.Config{}, hotel, from)
+
+			//nolint:exhaustruct
+			_, err := manager.CreateOrder(booking.NewContextWithIdempotencyKey(context.Background(), "key"), &booking.BookInput{
+				Payer:  booking.Payer{Email: "guest@mail.ru"},
+				Places: []booking.Place{luxPlace(from, 1)},
+			})
+			if !errors.Is(err, booking.ErrInvalidTaxRule) {
+				t.Errorf("order in hotel with tax rule %+v got %v, expected %v", tt.rule, err, booking.ErrInvalidTaxRule)
+			}
+		})
+	}
+}
@@ -0,0 +1,178 @@
+package booking
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+type UpdateOrderInput struct {
+	Places []Place `json:"places"`
+}
+
+func (u *UpdateOrderInput) validate(hotels map[string]*Hotel) error {
+	inputErr := NewInputError()
+
+	validatePlaces(inputErr, u.Places, hotels)
+
+	if inputErr.FieldsCount() > 0 {
+		return inputErr
+	}
+
+	return nil
+}
+
+// modifiable reports whether places of the order can be replaced. A held order is modifiable until its hold expires.
+func (o *Order) modifiable(now time.Time) bool {
+	if o.Status == OrderStatusHeld {
+		return !o.holdExpired(now)
+	}
+
+	return o.Status == OrderStatusPending || o.Status == OrderStatusConfirmed
+}
+
+// holdExpired reports whether the order is held and its hold time is over.
+func (o *Order) holdExpired(now time.Time) bool {
+	return o.Status == OrderStatusHeld && o.HoldExpiresAt != nil && now.After(*o.HoldExpiresAt)
+}
+
+// addedNights returns nights for which the change takes more rooms than the order had.
+func addedNights(diff map[string]*night) map[string]*night {
+	added := make(map[string]*night)
+
+	for key, n := range diff {
+		if n.Count > 0 {
+			added[key] = n
+		}
+	}
+
+	return added
+}
+
+// UpdateOrder replaces places of the order. Quota is taken for added nights and returned for dropped ones
+// in one transaction. Added nights go through the same availability check as a new order, so one
+// unavailable night fails the whole change.
+func (m *Manager) UpdateOrder(ctx context.Context, id int, input *UpdateOrderInput) (*Order, error) {
+	requestFingerprint, err := input.fingerprint()
+	if err != nil {
+		return nil, fmt.Errorf("fingerprint request: %w", err)
+	}
+
+	// Like CreateOrder, a retry of a completed change is answered before validation.
+	return m.idempotent(ctx, scopeUpdateOrder(id), requestFingerprint, func(ctx context.Context) (order *Order, err error) {
+		hotels, err := m.getHotels(ctx, input.Places)
+		if err != nil {
+			return nil, fmt.Errorf("get hotels: %w", err)
+		}
+
+		if err := input.validate(hotels); err != nil {
+			return nil, err
+		}
+
+		preparePlaces(input.Places, hotels)
+
+		err = m.retryOnConflict(ctx, func() error {
+			order, err = m.updateOrder(ctx, id, input, hotels)
+
+			return err
+		})
+
+		return order, err
+	})
+}
+
+func (m *Manager) updateOrder(ctx context.Context, id int, input *UpdateOrderInput, hotels map[string]*Hotel) (*Order, error) {
+	order, err := m.storage.GetOrder(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("get order %v: %w", id, err)
+	}
+
+	now := time.Now().UTC()
+
+	if order.holdExpired(now) {
+		return nil, fmt.Errorf("update order %v: %w", id, ErrHoldExpired)
+	}
+
+	if !order.modifiable(now) {
+		return nil, fmt.Errorf("update order %v in status '%v': %w", id, order.Status, ErrOrderNotModifiable)
+	}
+
+	diff := diffNights(order.Places, input.Places)
+
+	var availabilityErr, restrictionErr error
+
+	if added := addedNights(diff); len(added) > 0 {
+		availabilityErr = m.checkAvailability(ctx, added)
+	}
+
+	if changed := changedPlaces(order.Places, input.Places); len(changed) > 0 {
+		restrictionErr = m.checkRestrictions(ctx, changed)
+	}
+
+	if err := joinBookingErrors(availabilityErr, restrictionErr); err != nil {
+		return nil, err
+	}
+
+	order.Places = input.Places
+	order.UpdatedAt = now
+	order.Version++
+
+	// The order is priced like a new one, so its promo codes and the payer's loyalty discount apply
+	// to the new places.
+	if err := m.priceOrder(ctx, order); err != nil {
+		return nil, fmt.Errorf("price order: %w", err)
+	}
+
+	strategies, rejected, err := m.orderBoostStrategies(ctx, order)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := order.applyBoosts(strategies); err != nil {
+		return nil, err
+	}
+
+	// Promo codes which no longer apply to the changed places are reported rather than failing the change.
+	order.RejectedBoosts = append(order.RejectedBoosts, rejected...)
+
+	if err := order.applyTaxes(hotels); err != nil {
+		return nil, fmt.Errorf("apply taxes: %w", err)
+	}
+
+	if order.Display != nil {
+		if err := m.convertPrice(ctx, order, order.Display.Price.Currency); err != nil {
+			return nil, fmt.Errorf("convert price: %w", err)
+		}
+	}
+
+	event, err := m.buildEvent(ctx, order.ID, EventOrderUpdated, order.Status, order.Status)
+	if err != nil {
+		return nil, fmt.Errorf("build event for order %v: %w", order.ID, err)
+	}
+
+	err = m.inTransaction(ctx, func(ctx context.Context) error {
+		if err := m.storage.SaveOrder(ctx, order); err != nil {
+			return fmt.Errorf("save order to storage: %w", err)
+		}
+
+		// Added nights have positive counts in the diff and take quota, dropped ones give it back.
+		if err := m.storage.AdjustQuotas(ctx, quotaChanges(diff, -1)); err != nil {
+			return fmt.Errorf("change quotas in storage: %w", err)
+		}
+
+		if err := m.completeIdempotencyKey(ctx, order); err != nil {
+			return err
+		}
+
+		if err := m.storage.SaveEvent(ctx, event); err != nil {
+			return fmt.Errorf("save event to storage: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return order, nil
+}
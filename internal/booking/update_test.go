@@ -0,0 +1,254 @@
+package booking_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/avstrong/booking/internal/booking"
+	"github.com/avstrong/booking/internal/boost"
+	"github.com/avstrong/booking/internal/money"
+)
+
+func TestManager_UpdateOrderChangesQuota(t *testing.T) {
+	t.Parallel()
+
+	from := startDate()
+
+	tests := []struct {
+		name   string
+		places []booking.Place
+		quotas []int
+	}{
+		{
+			name:   "moved stay takes new nights and returns dropped ones",
+			places: []booking.Place{luxPlace(from.AddDate(0, 0, 1), 2)},
+			quotas: []int{quota, quota - 1, quota - 1},
+		},
+		{
+			name:   "added place takes its nights only",
+			places: []booking.Place{luxPlace(from, 2), luxPlace(from.AddDate(0, 0, 1), 1)},
+			quotas: []int{quota - 1, quota - 2, quota},
+		},
+		{
+			name:   "shortened stay returns dropped nights",
+			places: []booking.Place{luxPlace(from, 1)},
+			quotas: []int{quota - 1, quota, quota},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			//nolint:exhaustruct
+			manager, db := newManager(t, booking.Config{}, newHotel(), from)
+			order := createOrder(t, manager, "create", luxPlace(from, 2))
+
+			ctx := booking.NewContextWithIdempotencyKey(context.Background(), "update")
+
+			updated, err := manager.UpdateOrder(ctx, order.ID, &booking.UpdateOrderInput{Places: tt.places})
+			if err != nil {
+				t.Fatalf("update order: %v", err)
+			}
+
+			if len(updated.Places) != len(tt.places) {
+				t.Errorf("updated order has places %+v, expected %+v", updated.Places, tt.places)
+			}
+
+			if got := quotas(t, db, from); !slices.Equal(got, tt.quotas) {
+				t.Errorf("quotas are %v, expected %v", got, tt.quotas)
+			}
+		})
+	}
+}
+
+func TestManager_UpdateOrderFailsWithUnavailableNight(t *testing.T) {
+	t.Parallel()
+
+	from := startDate()
+	//nolint:exhaustruct
+	manager, db := newManager(t, booking.Config{}, newHotel(), from)
+	order := createOrder(t, manager, "create", luxPlace(from, 1))
+	before := quotas(t, db, from)
+
+	// The first place alone could be moved, but nights of the second one are not on sale.
+	places := []booking.Place{luxPlace(from.AddDate(0, 0, 1), 1), luxPlace(from.AddDate(0, 0, 10), 1)}
+
+	_, err := manager.UpdateOrder(booking.NewContextWithIdempotencyKey(context.Background(), "update"), order.ID,
+		&booking.UpdateOrderInput{Places: places})
+	if booking.IsAvailabilityError(err) == nil {
+		t.Fatalf("update order got %v, expected availability error", err)
+	}
+
+	if got := quotas(t, db, from); !slices.Equal(got, before) {
+		t.Errorf("quotas are %v after a failed change, expected %v", got, before)
+	}
+
+	stored, err := db.GetOrder(context.Background(), order.ID)
+	if err != nil {
+		t.Fatalf("get order: %v", err)
+	}
+
+	if len(stored.Places) != 1 || !stored.Places[0].From.Equal(order.Places[0].From) {
+		t.Errorf("order has places %+v after a failed change, expected %+v", stored.Places, order.Places)
+	}
+}
+
+func TestManager_UpdateOrderRejectsFinalOrder(t *testing.T) {
+	t.Parallel()
+
+	from := startDate()
+	//nolint:exhaustruct
+	manager, _ := newManager(t, booking.Config{}, newHotel(), from)
+	order := createOrder(t, manager, "create", luxPlace(from, 1))
+
+	if _, err := manager.CancelOrder(context.Background(), order.ID); err != nil {
+		t.Fatalf("cancel order: %v", err)
+	}
+
+	_, err := manager.UpdateOrder(booking.NewContextWithIdempotencyKey(context.Background(), "update"), order.ID,
+		&booking.UpdateOrderInput{Places: []booking.Place{luxPlace(from, 2)}})
+	if !errors.Is(err, booking.ErrOrderNotModifiable) {
+		t.Errorf("update of a cancelled order got %v, expected %v", err, booking.ErrOrderNotModifiable)
+	}
+}
+
+// bookWithPromo books the place with the promo code.
+func bookWithPromo(t *testing.T, manager *booking.Manager, place booking.Place, code string) *booking.Order {
+	t.Helper()
+
+	//nolint:exhaustruct
+	order, err := manager.CreateOrder(booking.NewContextWithIdempotencyKey(context.Background(), "create"), &booking.BookInput{
+		Payer:      booking.Payer{Email: "guest@mail.ru"},
+		Places:     []booking.Place{place},
+		PromoCodes: []string{code},
+	})
+	if err != nil {
+		t.Fatalf("create order: %v", err)
+	}
+
+	return order
+}
+
+// promoDiscounted tells whether the order has a discount of the promo code.
+func promoDiscounted(order *booking.Order, code string) bool {
+	for _, item := range order.LineItems {
+		if item.Type == booking.LineItemDiscount && item.StrategyCode == code {
+			return true
+		}
+	}
+
+	return false
+}
+
+func TestManager_UpdateOrderKeepsPromoDiscount(t *testing.T) {
+	t.Parallel()
+
+	from := startDate()
+	db := newDB(t, newHotel(), from)
+	//nolint:exhaustruct
+	savePromo(t, db, &boost.Promo{Code: "spring", DiscountType: boost.DiscountPercent, Percentage: 10})
+	//nolint:exhaustruct
+	manager := newBoostedManager(db, boost.Config{})
+
+	order := bookWithPromo(t, manager, luxPlace(from, 2), "spring")
+	if want := money.FromMajor(9000, "RUB"); order.Price != want {
+		t.Fatalf("order price is %v, expected %v", order.Price, want)
+	}
+
+	updated, err := manager.UpdateOrder(booking.NewContextWithIdempotencyKey(context.Background(), "update"), order.ID,
+		&booking.UpdateOrderInput{Places: []booking.Place{luxPlace(from, 3)}})
+	if err != nil {
+		t.Fatalf("update order: %v", err)
+	}
+
+	// Three nights at 5000 RUB with 10% off.
+	if want := money.FromMajor(13500, "RUB"); updated.Price != want {
+		t.Errorf("updated order price is %v, expected %v", updated.Price, want)
+	}
+
+	if !promoDiscounted(updated, "spring") {
+		t.Errorf("updated order has no discount of its promo code: %+v", updated.LineItems)
+	}
+}
+
+func TestManager_UpdateOrderRejectsInapplicablePromo(t *testing.T) {
+	t.Parallel()
+
+	from := startDate()
+	db := newDB(t, newHotel(), from)
+	//nolint:exhaustruct
+	savePromo(t, db, &boost.Promo{Code: "long", DiscountType: boost.DiscountPercent, Percentage: 10, MinNights: 2})
+	//nolint:exhaustruct
+	manager := newBoostedManager(db, boost.Config{})
+
+	//nolint:exhaustruct
+	_, err := manager.CreateOrder(booking.NewContextWithIdempotencyKey(context.Background(), "short"), &booking.BookInput{
+		Payer:      booking.Payer{Email: "guest@mail.ru"},
+		Places:     []booking.Place{luxPlace(from, 1)},
+		PromoCodes: []string{"long"},
+	})
+	if booking.IsInputError(err) == nil {
+		t.Errorf("booking with a promo code for longer stays got %v, expected input error", err)
+	}
+
+	order := bookWithPromo(t, manager, luxPlace(from, 2), "long")
+
+	// The stay gets shorter than the promo code allows, which is reported but does not stop the change.
+	updated, err := manager.UpdateOrder(booking.NewContextWithIdempotencyKey(context.Background(), "update"), order.ID,
+		&booking.UpdateOrderInput{Places: []booking.Place{luxPlace(from, 1)}})
+	if err != nil {
+		t.Fatalf("update order: %v", err)
+	}
+
+	if want := money.FromMajor(5000, "RUB"); updated.Price != want {
+		t.Errorf("updated order price is %v, expected %v", updated.Price, want)
+	}
+
+	if promoDiscounted(updated, "long") {
+		t.Errorf("updated order is discounted by a promo code for longer stays: %+v", updated.LineItems)
+	}
+
+	if len(updated.RejectedBoosts) != 1 || updated.RejectedBoosts[0].StrategyCode != "long" {
+		t.Errorf("updated order has rejected boosts %+v, expected the promo code for longer stays", updated.RejectedBoosts)
+	}
+}
+
+func TestManager_UpdateOrderKeepsLoyaltyDiscount(t *testing.T) {
+	t.Parallel()
+
+	from := startDate()
+	db := newDB(t, newHotel(), from)
+	manager := newBoostedManager(db, boost.Config{Loyalty: boost.LoyaltyProgram{
+		Window: 365 * 24 * time.Hour,
+		//nolint:exhaustruct
+		Tiers: []boost.LoyaltyTier{{Name: "silver", MinNights: 2, DiscountPercentage: 10}},
+	}})
+
+	// The first stay earns the silver tier.
+	stay := createOrder(t, manager, "stay", luxPlace(from, 2))
+	for _, status := range []booking.OrderStatus{booking.OrderStatusConfirmed, booking.OrderStatusCheckedIn, booking.OrderStatusCompleted} {
+		if _, err := manager.ChangeOrderStatus(context.Background(), stay.ID, status); err != nil {
+			t.Fatalf("change status to %v: %v", status, err)
+		}
+	}
+
+	order := createOrder(t, manager, "order", luxPlace(from.AddDate(0, 0, 2), 1))
+	if want := money.FromMajor(4500, "RUB"); order.Price != want {
+		t.Fatalf("order price is %v, expected %v", order.Price, want)
+	}
+
+	updated, err := manager.UpdateOrder(booking.NewContextWithIdempotencyKey(context.Background(), "update"), order.ID,
+		&booking.UpdateOrderInput{Places: []booking.Place{luxPlace(from.AddDate(0, 0, 1), 2)}})
+	if err != nil {
+		t.Fatalf("update order: %v", err)
+	}
+
+	// Two nights at 5000 RUB with 10% off.
+	if want := money.FromMajor(9000, "RUB"); updated.Price != want {
+		t.Errorf("updated order price is %v, expected %v", updated.Price, want)
+	}
+}
@@ -2,4 +2,4 @@ package boost
 
 import "errors"
 
-var ErrPromoCodeExpired = errors.New("promo code expired")
+var ErrUnknownDiscountType = errors.New("unknown discount type")
@@ -0,0 +1,189 @@
+package boost
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/avstrong/booking/internal/booking"
+	"github.com/avstrong/booking/internal/money"
+)
+
+// LoyaltyProgram gives payers tiers by their completed stays within a rolling window.
+type LoyaltyProgram struct {
+	// Window is how far back completed stays count, e.g. a year.
+	Window time.Duration
+	// Tiers are sorted from the lowest to the highest. A payer gets the highest tier they qualify for.
+	Tiers []LoyaltyTier
+}
+
+// LoyaltyTier is reached by enough nights or enough spend, whichever comes first.
+type LoyaltyTier struct {
+	Name string
+	// MinNights is the number of nights of completed stays. Zero means nights do not qualify.
+	MinNights int
+	// MinSpend is the total price of completed orders in its currency. Orders in other currencies do not
+	// count. Zero means spend does not qualify.
+	MinSpend           money.Money
+	DiscountPercentage float64
+	// Perks are services the tier gets on top of the discount, e.g. "late_checkout".
+	Perks []string
+}
+
+// completedStay is a completed order which counts for the loyalty program.
+type completedStay struct {
+	endsAt time.Time
+	nights int
+	spend  money.Money
+}
+
+// qualifies reports whether the stays reach the tier and when the tier lapses: the moment the oldest
+// of the most recent stays needed for the tier leaves the window.
+func (t *LoyaltyTier) qualifies(stays []completedStay, window time.Duration) (bool, time.Time) {
+	var (
+		nights int
+		spend  int64
+	)
+
+	for _, stay := range stays {
+		nights += stay.nights
+
+		if stay.spend.Currency == t.MinSpend.Currency {
+			spend += stay.spend.Amount
+		}
+
+		if (t.MinNights > 0 && nights >= t.MinNights) || (t.MinSpend.Amount > 0 && spend >= t.MinSpend.Amount) {
+			return true, stay.endsAt.Add(window)
+		}
+	}
+
+	return false, time.Time{}
+}
+
+// LoyaltyDiscount is the discount of the payer's loyalty tier. It applies to orders of the payer only
+// and not after ValidThrough.
+type LoyaltyDiscount struct {
+	// CustomerID is the email of the payer.
+	CustomerID         string
+	Tier               string
+	DiscountPercentage float64
+	Perks              []string
+	ValidThrough       time.Time
+}
+
+// Rules put loyalty discounts in one group, so a payer gets at most one of them.
+func (l *LoyaltyDiscount) Rules() booking.BoostRules {
+	//nolint:exhaustruct // loyalty discounts stack with promo codes
+	return booking.BoostRules{
+		StrategyType: StrategyLoyalty,
+		StrategyCode: l.Tier,
+		Group:        StrategyLoyalty,
+	}
+}
+
+// Apply discounts every place by the tier's percentage and lists the tier's perks.
+func (l *LoyaltyDiscount) Apply(order *booking.Order) ([]booking.LineItem, error) {
+	if !strings.EqualFold(order.Payer.Email, l.CustomerID) || time.Now().UTC().After(l.ValidThrough) {
+		return nil, nil
+	}
+
+	items := make([]booking.LineItem, 0, len(order.Places)+len(l.Perks))
+
+	if l.DiscountPercentage > 0 {
+		for _, place := range order.Places {
+			//nolint:exhaustruct // discount is for the whole stay
+			items = append(items, booking.LineItem{
+				Type:         booking.LineItemDiscount,
+				HotelID:      place.HotelID,
+				RoomID:       place.RoomID,
+				StrategyType: StrategyLoyalty,
+				StrategyCode: l.Tier,
+				Amount:       place.Price.Percent(l.DiscountPercentage).Neg(),
+			})
+		}
+	}
+
+	for _, perk := range l.Perks {
+		//nolint:exhaustruct // perks are for the whole order
+		items = append(items, booking.LineItem{
+			Type:         booking.LineItemPerk,
+			StrategyType: StrategyLoyalty,
+			StrategyCode: l.Tier,
+			Perk:         perk,
+			Amount:       money.New(0, order.Price.Currency),
+		})
+	}
+
+	return items, nil
+}
+
+// loyaltyStrategy returns the discount of the payer's tier or nil if the payer has no tier.
+func (m *Manager) loyaltyStrategy(ctx context.Context, payerEmail string) (*LoyaltyDiscount, error) {
+	program := m.conf.Loyalty
+	if len(program.Tiers) == 0 || payerEmail == "" {
+		return nil, nil
+	}
+
+	stays, err := m.completedStays(ctx, payerEmail, program.Window)
+	if err != nil {
+		return nil, err
+	}
+
+	for idx := len(program.Tiers) - 1; idx >= 0; idx-- {
+		tier := program.Tiers[idx]
+
+		if ok, validThrough := tier.qualifies(stays, program.Window); ok {
+			return &LoyaltyDiscount{
+				CustomerID:         payerEmail,
+				Tier:               tier.Name,
+				DiscountPercentage: tier.DiscountPercentage,
+				Perks:              tier.Perks,
+				ValidThrough:       validThrough,
+			}, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// completedStays returns completed orders of the payer which ended within the window, the most recent first.
+func (m *Manager) completedStays(ctx context.Context, payerEmail string, window time.Duration) ([]completedStay, error) {
+	now := time.Now().UTC()
+
+	//nolint:exhaustruct
+	orders, err := m.storage.ListOrders(ctx, booking.OrderFilter{
+		PayerEmail: payerEmail,
+		Statuses:   []booking.OrderStatus{booking.OrderStatusCompleted},
+		StayFrom:   now.Add(-window),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list completed orders of payer from storage: %w", err)
+	}
+
+	stays := make([]completedStay, 0, len(orders))
+
+	for _, order := range orders {
+		//nolint:exhaustruct // filled below
+		stay := completedStay{spend: order.Price}
+
+		for _, place := range order.Places {
+			stay.nights += place.Nights()
+
+			if place.CheckOut.After(stay.endsAt) {
+				stay.endsAt = place.CheckOut
+			}
+		}
+
+		if stay.endsAt.After(now.Add(-window)) {
+			stays = append(stays, stay)
+		}
+	}
+
+	sort.Slice(stays, func(i, j int) bool {
+		return stays[i].endsAt.After(stays[j].endsAt)
+	})
+
+	return stays, nil
+}
@@ -3,80 +3,299 @@ package boost
 import (
 	"context"
 	"fmt"
+	"slices"
 	"time"
 
 	"github.com/avstrong/booking/internal/booking"
+	"github.com/avstrong/booking/internal/money"
+)
+
+// Strategy types are reported on discount line items.
+const (
+	StrategyPromoCode = "promo_code"
+	StrategyLoyalty   = "loyalty"
+)
+
+const (
+	promoCodesField = "promo_codes"
+	// maxPromoCodes limits codes of one request, since booking evaluates every combination of them.
+	maxPromoCodes = 5
 )
 
 type storage interface {
-	GetAvailablePromo(ctx context.Context, from time.Time) ([]string, error)
+	// GetPromoCodes returns promo codes of the catalog which exist among the codes.
+	GetPromoCodes(ctx context.Context, codes []string) ([]*Promo, error)
+	ListOrders(ctx context.Context, filter booking.OrderFilter) ([]*booking.Order, error)
+}
+
+type Config struct {
+	Loyalty LoyaltyProgram
 }
 
 type Manager struct {
+	conf    Config
 	storage storage
 }
 
-func New(storage storage) *Manager {
-	return &Manager{storage: storage}
+func New(conf Config, storage storage) *Manager {
+	return &Manager{conf: conf, storage: storage}
 }
 
-type PromoCode struct {
-	Code               string
-	DiscountPercentage float64
-	ValidThrough       time.Time
+// Strategies returns strategies for the promo codes of the request and the loyalty discount of its payer.
+func (m *Manager) Strategies(ctx context.Context, input *booking.BookInput) ([]booking.BoostStrategy, error) {
+	strategies, err := m.promoStrategies(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	loyalty, err := m.loyaltyStrategy(ctx, input.Payer.Email)
+	if err != nil {
+		return nil, err
+	}
+
+	if loyalty != nil {
+		strategies = append(strategies, loyalty)
+	}
+
+	return strategies, nil
+}
+
+type DiscountType string
+
+const (
+	// DiscountPercent takes a percentage of the price of every eligible place.
+	DiscountPercent DiscountType = "percent"
+	// DiscountFixed takes a fixed amount of the eligible places together.
+	DiscountFixed DiscountType = "fixed"
+)
+
+// Promo is a promo code of the catalog.
+type Promo struct {
+	Code         string       `json:"code"`
+	DiscountType DiscountType `json:"discount_type"`
+	Percentage   float64      `json:"percentage,omitempty"`
+	Amount       money.Money  `json:"amount"`
+	// The code can be used from ValidFrom until ValidThrough.
+	ValidFrom    time.Time `json:"valid_from"`
+	ValidThrough time.Time `json:"valid_through"`
+	// HotelIDs and RoomIDs limit the places which are discounted. Empty lists allow any.
+	HotelIDs []string `json:"hotel_ids,omitempty"`
+	RoomIDs  []string `json:"room_ids,omitempty"`
+	// MinNights is the shortest stay of a place which is discounted.
+	MinNights int `json:"min_nights"`
+	// MaxRedemptions limits usages of the code by all orders, MaxRedemptionsPerPayer by orders of one payer.
+	// Zero means no limit. Cancelled orders do not count.
+	MaxRedemptions         int `json:"max_redemptions"`
+	MaxRedemptionsPerPayer int `json:"max_redemptions_per_payer"`
+	// Priority, Group, Exclusive and MaxDiscountPercentage are stacking rules of the code,
+	// see booking.BoostRules.
+	Priority              int     `json:"priority"`
+	Group                 string  `json:"group,omitempty"`
+	Exclusive             bool    `json:"exclusive"`
+	MaxDiscountPercentage float64 `json:"max_discount_percentage,omitempty"`
+}
+
+func (p *Promo) valid(now time.Time) bool {
+	return !now.Before(p.ValidFrom) && !now.After(p.ValidThrough)
 }
 
-func (p *PromoCode) Apply(order *booking.Order) error {
-	if time.Now().UTC().After(p.ValidThrough) {
-		return fmt.Errorf("promo code %s expired: %w", p.Code, ErrPromoCodeExpired)
+func (p *Promo) eligible(place *booking.Place) bool {
+	if len(p.HotelIDs) > 0 && !slices.Contains(p.HotelIDs, place.HotelID) {
+		return false
 	}
 
-	for i := range order.Places {
-		order.Places[i].Price -= order.Places[i].Price * p.DiscountPercentage / 100 //nolint:gomnd // for test reason
+	if len(p.RoomIDs) > 0 && !slices.Contains(p.RoomIDs, place.RoomID) {
+		return false
 	}
 
-	return nil
+	return place.Nights() >= p.MinNights
 }
 
-type LoyaltyDiscount struct {
-	CustomerID     string
-	DiscountAmount float64
-	ValidThrough   time.Time
+// inapplicable explains why the promo code can not discount the priced order, or returns an empty string.
+func (p *Promo) inapplicable(order *booking.Order) string {
+	if !slices.ContainsFunc(order.Places, func(place booking.Place) bool { return p.eligible(&place) }) {
+		return "not applicable to the booked places"
+	}
+
+	if p.DiscountType == DiscountFixed && p.Amount.Currency != order.Price.Currency {
+		return fmt.Sprintf("can not be used for prices in %v", order.Price.Currency)
+	}
+
+	return ""
 }
 
-func (l *LoyaltyDiscount) Apply(order *booking.Order) error {
-	// Проверить уровень лояльности клиента и применить скидку
-	order.Price -= l.DiscountAmount
+// PromoCode is a promo code the client asked to use for an order.
+type PromoCode struct {
+	Promo *Promo
+}
 
-	return nil
+func (p *PromoCode) Rules() booking.BoostRules {
+	return booking.BoostRules{
+		StrategyType:          StrategyPromoCode,
+		StrategyCode:          p.Promo.Code,
+		Priority:              p.Promo.Priority,
+		Group:                 p.Promo.Group,
+		Exclusive:             p.Promo.Exclusive,
+		MaxDiscountPercentage: p.Promo.MaxDiscountPercentage,
+	}
 }
 
-func (m *Manager) Strategies(ctx context.Context) ([]booking.BoostStrategy, error) {
-	now := time.Now().UTC()
+// Apply discounts places of the order which the promo code is eligible for.
+func (p *PromoCode) Apply(order *booking.Order) ([]booking.LineItem, error) {
+	if reason := p.Promo.inapplicable(order); reason != "" {
+		return nil, promoError(fmt.Sprintf("promo code '%v' is %v", p.Promo.Code, reason))
+	}
+
+	var (
+		eligible []booking.Place
+		total    int64
+	)
+
+	for _, place := range order.Places {
+		if p.Promo.eligible(&place) {
+			eligible = append(eligible, place)
+			total += place.Price.Amount
+		}
+	}
 
-	res, err := m.storage.GetAvailablePromo(ctx, time.Now())
+	items := make([]booking.LineItem, 0, len(eligible))
+	left := p.Promo.Amount
+
+	for idx, place := range eligible {
+		var discount money.Money
+
+		switch p.Promo.DiscountType {
+		case DiscountPercent:
+			discount = place.Price.Percent(p.Promo.Percentage)
+		case DiscountFixed:
+			// The fixed amount is split between places in proportion to their prices, the last one takes the rest.
+			discount = p.Promo.Amount.Ratio(place.Price.Amount, total)
+			if idx == len(eligible)-1 {
+				discount = left
+			}
+
+			left.Amount -= discount.Amount
+
+			if discount.Amount > place.Price.Amount {
+				discount = place.Price
+			}
+		default:
+			return nil, fmt.Errorf("promo code %v: '%v': %w", p.Promo.Code, p.Promo.DiscountType, ErrUnknownDiscountType)
+		}
+
+		//nolint:exhaustruct // discount is for the whole stay
+		items = append(items, booking.LineItem{
+			Type:         booking.LineItemDiscount,
+			HotelID:      place.HotelID,
+			RoomID:       place.RoomID,
+			StrategyType: StrategyPromoCode,
+			StrategyCode: p.Promo.Code,
+			Amount:       discount.Neg(),
+		})
+	}
+
+	return items, nil
+}
+
+func promoError(msg string) error {
+	inputErr := booking.NewInputError()
+	inputErr.AddError(promoCodesField, msg)
+
+	return inputErr
+}
+
+// promoStrategies returns strategies for the promo codes of the request. Unknown, repeated and not yet or no longer
+// valid codes are reported as an InputError. Limits of the codes are checked when the booking is saved.
+func (m *Manager) promoStrategies(ctx context.Context, input *booking.BookInput) ([]booking.BoostStrategy, error) {
+	if len(input.PromoCodes) == 0 {
+		return nil, nil
+	}
+
+	if len(input.PromoCodes) > maxPromoCodes {
+		return nil, promoError(fmt.Sprintf("use at most %v promo codes", maxPromoCodes))
+	}
+
+	promos, err := m.storage.GetPromoCodes(ctx, input.PromoCodes)
 	if err != nil {
-		return nil, fmt.Errorf("get available promo from storage starting from %v: %w", now, err)
+		return nil, fmt.Errorf("get promo codes from storage: %w", err)
 	}
 
-	// Some logic
+	catalog := make(map[string]*Promo, len(promos))
+	for _, promo := range promos {
+		catalog[promo.Code] = promo
+	}
 
-	_ = res
+	now := time.Now().UTC()
+	inputErr := booking.NewInputError()
+	strategies := make([]booking.BoostStrategy, 0, len(input.PromoCodes))
+	seen := make(map[string]bool, len(input.PromoCodes))
+
+	for _, code := range input.PromoCodes {
+		promo, ok := catalog[code]
+
+		switch {
+		case seen[code]:
+			inputErr.AddError(promoCodesField, fmt.Sprintf("promo code '%v' is used more than once", code))
+		case !ok:
+			inputErr.AddError(promoCodesField, fmt.Sprintf("unknown promo code '%v'", code))
+		case !promo.valid(now):
+			inputErr.AddError(promoCodesField, fmt.Sprintf("promo code '%v' is not valid now", code))
+		default:
+			strategies = append(strategies, &PromoCode{Promo: promo})
+		}
+
+		seen[code] = true
+	}
+
+	if inputErr.FieldsCount() > 0 {
+		return nil, inputErr
+	}
+
+	return strategies, nil
+}
+
+// OrderStrategies returns strategies for re-pricing a booked order: its promo codes, which were checked when
+// it was booked, and the current loyalty discount of its payer. Validity windows of the codes are not checked
+// again. Codes deleted from the catalog since then are left out, and codes which do not apply to the changed
+// places are rejected rather than failing the change.
+func (m *Manager) OrderStrategies(
+	ctx context.Context,
+	order *booking.Order,
+) ([]booking.BoostStrategy, []booking.RejectedBoost, error) {
+	var (
+		strategies []booking.BoostStrategy
+		rejected   []booking.RejectedBoost
+	)
+
+	if len(order.PromoCodes) > 0 {
+		promos, err := m.storage.GetPromoCodes(ctx, order.PromoCodes)
+		if err != nil {
+			return nil, nil, fmt.Errorf("get promo codes from storage: %w", err)
+		}
+
+		for _, promo := range promos {
+			if reason := promo.inapplicable(order); reason != "" {
+				rejected = append(rejected, booking.RejectedBoost{
+					StrategyType: StrategyPromoCode,
+					StrategyCode: promo.Code,
+					Reason:       reason,
+				})
 
-	promoCode := PromoCode{
-		Code:               "blackFriday",
-		DiscountPercentage: 35,                                        //nolint:gomnd // for test reason
-		ValidThrough:       time.Now().UTC().Add(time.Hour * 24 * 30), //nolint:gomnd // for test reason
+				continue
+			}
+
+			strategies = append(strategies, &PromoCode{Promo: promo})
+		}
+	}
+
+	loyalty, err := m.loyaltyStrategy(ctx, order.Payer.Email)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	loyaltyDiscount := LoyaltyDiscount{
-		CustomerID:     "test@test.com",
-		DiscountAmount: 300,                                       //nolint:gomnd // for test reason
-		ValidThrough:   time.Now().UTC().Add(time.Hour * 24 * 30), //nolint:gomnd // for test reason
+	if loyalty != nil {
+		strategies = append(strategies, loyalty)
 	}
 
-	return []booking.BoostStrategy{
-		&promoCode,
-		&loyaltyDiscount,
-	}, nil
+	return strategies, rejected, nil
 }
@@ -0,0 +1,8 @@
+package file
+
+import "errors"
+
+var (
+	ErrUnsupportedFormat = errors.New("unsupported exchange rates file format")
+	ErrInvalidRate       = errors.New("exchange rate must be a positive number")
+)
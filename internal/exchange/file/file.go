@@ -0,0 +1,252 @@
+// Package file provides exchange rates loaded from a local CSV or JSON file of daily rates.
+//
+// A CSV file has the header "date,from,to,rate", a JSON file is an array of objects with the same fields.
+// Dates are in 2006-01-02 format, and a rate is the price of one major unit of the from currency in major
+// units of the to currency. Reverse rates are derived when the file has no rate for the reverse pair.
+package file
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/avstrong/booking/internal/booking"
+	"github.com/avstrong/booking/internal/logger"
+	"github.com/avstrong/booking/internal/money"
+)
+
+const dateLayout = "2006-01-02"
+
+type Config struct {
+	L    *logger.Logger
+	Path string
+}
+
+type pair struct {
+	from money.Currency
+	to   money.Currency
+}
+
+// Rates keeps rates of the file in memory. They are replaced as a whole on reload.
+type Rates struct {
+	mu      sync.RWMutex
+	l       *logger.Logger
+	path    string
+	modTime time.Time
+	// rates of every pair are sorted by date.
+	rates map[pair][]*booking.ExchangeRate
+}
+
+// New loads the rates file and fails when it can not be read.
+func New(conf Config) (*Rates, error) {
+	//nolint:exhaustruct
+	r := &Rates{
+		l:    conf.L,
+		path: conf.Path,
+	}
+
+	if _, err := r.Reload(); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// Reload reads the file again if it has changed since the last load and reports whether it did.
+// The loaded rates are kept when the new file is invalid.
+func (r *Rates) Reload() (bool, error) {
+	info, err := os.Stat(r.path)
+	if err != nil {
+		return false, fmt.Errorf("stat exchange rates file: %w", err)
+	}
+
+	r.mu.RLock()
+	unchanged := info.ModTime().Equal(r.modTime)
+	r.mu.RUnlock()
+
+	if unchanged {
+		return false, nil
+	}
+
+	rates, err := load(r.path)
+	if err != nil {
+		return false, err
+	}
+
+	r.mu.Lock()
+	r.rates = index(rates)
+	r.modTime = info.ModTime()
+	r.mu.Unlock()
+
+	r.l.LogInfo("Loaded %v exchange rates from %v", len(rates), r.path)
+
+	return true, nil
+}
+
+func (r *Rates) ExchangeRate(_ context.Context, from, to money.Currency, date time.Time) (*booking.ExchangeRate, error) {
+	if from == to {
+		return &booking.ExchangeRate{From: from, To: to, Date: date, Rate: 1}, nil
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	rates := r.rates[pair{from: from, to: to}]
+
+	// The latest rate published on or before the date.
+	idx := sort.Search(len(rates), func(i int) bool {
+		return rates[i].Date.After(date)
+	})
+	if idx == 0 {
+		return nil, fmt.Errorf("%v to %v on %v: %w", from, to, date.Format(dateLayout), booking.ErrExchangeRateNotFound)
+	}
+
+	rate := *rates[idx-1]
+
+	return &rate, nil
+}
+
+// index groups rates by pairs and adds reverse rates which the file does not have.
+func index(rates []*booking.ExchangeRate) map[pair][]*booking.ExchangeRate {
+	result := make(map[pair][]*booking.ExchangeRate)
+	given := make(map[pair]map[time.Time]bool)
+
+	for _, rate := range rates {
+		p := pair{from: rate.From, to: rate.To}
+		if given[p] == nil {
+			given[p] = make(map[time.Time]bool)
+		}
+
+		given[p][rate.Date] = true
+		result[p] = append(result[p], rate)
+	}
+
+	for _, rate := range rates {
+		reverse := pair{from: rate.To, to: rate.From}
+		if given[reverse][rate.Date] {
+			continue
+		}
+
+		result[reverse] = append(result[reverse], &booking.ExchangeRate{
+			From: rate.To,
+			To:   rate.From,
+			Date: rate.Date,
+			Rate: 1 / rate.Rate,
+		})
+	}
+
+	for _, pairRates := range result {
+		sort.Slice(pairRates, func(i, j int) bool {
+			return pairRates[i].Date.Before(pairRates[j].Date)
+		})
+	}
+
+	return result
+}
+
+type record struct {
+	Date string  `json:"date"`
+	From string  `json:"from"`
+	To   string  `json:"to"`
+	Rate float64 `json:"rate"`
+}
+
+func load(path string) ([]*booking.ExchangeRate, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open exchange rates file: %w", err)
+	}
+	defer f.Close()
+
+	var records []record
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		records, err = readCSV(f)
+	case ".json":
+		err = json.NewDecoder(f).Decode(&records)
+	default:
+		err = fmt.Errorf("%v: %w", filepath.Ext(path), ErrUnsupportedFormat)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("read exchange rates file %v: %w", path, err)
+	}
+
+	rates := make([]*booking.ExchangeRate, 0, len(records))
+
+	for idx, rec := range records {
+		rate, err := rec.parse()
+		if err != nil {
+			return nil, fmt.Errorf("exchange rate #%v in %v: %w", idx+1, path, err)
+		}
+
+		rates = append(rates, rate)
+	}
+
+	return rates, nil
+}
+
+func readCSV(r io.Reader) ([]record, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = 4 //nolint:gomnd // date,from,to,rate
+	reader.TrimLeadingSpace = true
+
+	var records []record
+
+	for line := 1; ; line++ {
+		row, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return records, nil
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("read csv: %w", err)
+		}
+
+		if line == 1 && row[0] == "date" {
+			continue
+		}
+
+		rate, err := strconv.ParseFloat(row[3], 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %v: parse rate: %w", line, err)
+		}
+
+		records = append(records, record{Date: row[0], From: row[1], To: row[2], Rate: rate})
+	}
+}
+
+func (rec *record) parse() (*booking.ExchangeRate, error) {
+	date, err := time.Parse(dateLayout, rec.Date)
+	if err != nil {
+		return nil, fmt.Errorf("parse date: %w", err)
+	}
+
+	from, err := money.ParseCurrency(rec.From)
+	if err != nil {
+		return nil, fmt.Errorf("parse from currency: %w", err)
+	}
+
+	to, err := money.ParseCurrency(rec.To)
+	if err != nil {
+		return nil, fmt.Errorf("parse to currency: %w", err)
+	}
+
+	if rec.Rate <= 0 || math.IsInf(rec.Rate, 0) || math.IsNaN(rec.Rate) {
+		return nil, fmt.Errorf("%v: %w", rec.Rate, ErrInvalidRate)
+	}
+
+	return &booking.ExchangeRate{From: from, To: to, Date: date, Rate: rec.Rate}, nil
+}
@@ -0,0 +1,226 @@
+package file_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/avstrong/booking/internal/booking"
+	"github.com/avstrong/booking/internal/exchange/file"
+	"github.com/avstrong/booking/internal/logger"
+	"github.com/avstrong/booking/internal/money"
+)
+
+const (
+	csvRates = `date,from,to,rate
+2024-03-01,RUB,USD,0.0110
+2024-03-10,RUB,USD,0.0100
+2024-03-01,USD,RUB,95
+`
+	jsonRates = `[
+	{"date": "2024-03-01", "from": "RUB", "to": "USD", "rate": 0.0110},
+	{"date": "2024-03-10", "from": "RUB", "to": "USD", "rate": 0.0100},
+	{"date": "2024-03-01", "from": "USD", "to": "RUB", "rate": 95}
+]`
+)
+
+// writeFile writes the content into a new file with the name in a temporary directory and returns its path.
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("write rates file: %v", err)
+	}
+
+	return path
+}
+
+func newRates(t *testing.T, path string) (*file.Rates, error) {
+	t.Helper()
+
+	return file.New(file.Config{L: logger.New(log.New(io.Discard, "", 0)), Path: path})
+}
+
+func date(day int) time.Time {
+	return time.Date(2024, 3, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestRates_ExchangeRate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		from money.Currency
+		to   money.Currency
+		date time.Time
+		want float64
+	}{
+		{name: "published on the date", from: "RUB", to: "USD", date: date(1), want: 0.0110},
+		{name: "latest before the date", from: "RUB", to: "USD", date: date(9), want: 0.0110},
+		{name: "later rate", from: "RUB", to: "USD", date: date(20), want: 0.0100},
+		{name: "given reverse rate is kept", from: "USD", to: "RUB", date: date(1), want: 95},
+		{name: "reverse rate is derived", from: "USD", to: "RUB", date: date(10), want: 100},
+		{name: "same currency", from: "EUR", to: "EUR", date: date(1), want: 1},
+	}
+
+	for _, format := range []struct {
+		name    string
+		content string
+	}{
+		{name: "rates.csv", content: csvRates},
+		{name: "rates.json", content: jsonRates},
+	} {
+		rates, err := newRates(t, writeFile(t, format.name, format.content))
+		if err != nil {
+			t.Fatalf("load %v: %v", format.name, err)
+		}
+
+		for _, tt := range tests {
+			t.Run(format.name+"/"+tt.name, func(t *testing.T) {
+				t.Parallel()
+
+				rate, err := rates.ExchangeRate(context.Background(), tt.from, tt.to, tt.date)
+				if err != nil {
+					t.Fatalf("get exchange rate: %v", err)
+				}
+
+				if rate.From != tt.from || rate.To != tt.to || !almostEqual(rate.Rate, tt.want) {
+					t.Errorf("rate is %+v, expected %v %v to %v", rate, tt.want, tt.from, tt.to)
+				}
+			})
+		}
+	}
+}
+
+func TestRates_ExchangeRateNotFound(t *testing.T) {
+	t.Parallel()
+
+	rates, err := newRates(t, writeFile(t, "rates.csv", csvRates))
+	if err != nil {
+		t.Fatalf("load rates: %v", err)
+	}
+
+	for _, tt := range []struct {
+		from money.Currency
+		to   money.Currency
+		date time.Time
+	}{
+		{from: "RUB", to: "USD", date: date(1).AddDate(0, 0, -1)},
+		{from: "RUB", to: "EUR", date: date(10)},
+	} {
+		if _, err := rates.ExchangeRate(context.Background(), tt.from, tt.to, tt.date); !errors.Is(err, booking.ErrExchangeRateNotFound) {
+			t.Errorf("%v to %v on %v got %v, expected %v", tt.from, tt.to, tt.date, err, booking.ErrExchangeRateNotFound)
+		}
+	}
+}
+
+func TestNew_RejectsInvalidFile(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		want    error
+	}{
+		{name: "unsupported format", file: "rates.txt", content: csvRates, want: file.ErrUnsupportedFormat},
+		{name: "zero rate", file: "rates.csv", content: "2024-03-01,RUB,USD,0\n", want: file.ErrInvalidRate},
+		{
+			name:    "negative rate",
+			file:    "rates.json",
+			content: `[{"date": "2024-03-01", "from": "RUB", "to": "USD", "rate": -1}]`,
+			want:    file.ErrInvalidRate,
+		},
+		{name: "unknown currency", file: "rates.csv", content: "2024-03-01,RUB,XXX,1\n", want: nil},
+		{name: "invalid date", file: "rates.csv", content: "01.03.2024,RUB,USD,1\n", want: nil},
+		{name: "missing field", file: "rates.csv", content: "2024-03-01,RUB,USD\n", want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := newRates(t, writeFile(t, tt.file, tt.content))
+			if err == nil || (tt.want != nil && !errors.Is(err, tt.want)) {
+				t.Errorf("load got %v, expected %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestNew_MissingFile(t *testing.T) {
+	t.Parallel()
+
+	if _, err := newRates(t, filepath.Join(t.TempDir(), "rates.csv")); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("load of a missing file got %v, expected %v", err, fs.ErrNotExist)
+	}
+}
+
+func TestRates_Reload(t *testing.T) {
+	t.Parallel()
+
+	path := writeFile(t, "rates.csv", csvRates)
+
+	rates, err := newRates(t, path)
+	if err != nil {
+		t.Fatalf("load rates: %v", err)
+	}
+
+	if reloaded, err := rates.Reload(); err != nil || reloaded {
+		t.Errorf("reload of an unchanged file got %v, %v, expected false without error", reloaded, err)
+	}
+
+	// Modification times are set explicitly, writes within one clock tick could leave the time unchanged.
+	update := func(content string, modTime time.Time) {
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("write rates file: %v", err)
+		}
+
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatalf("change modification time: %v", err)
+		}
+	}
+
+	update("date,from,to,rate\n2024-03-01,RUB,USD,0.0200\n", time.Now().Add(time.Minute))
+
+	if reloaded, err := rates.Reload(); err != nil || !reloaded {
+		t.Fatalf("reload of a changed file got %v, %v, expected true without error", reloaded, err)
+	}
+
+	assertRate(t, rates, 0.0200)
+
+	update("date,from,to,rate\n2024-03-01,RUB,USD,zero\n", time.Now().Add(2*time.Minute))
+
+	if _, err := rates.Reload(); err == nil {
+		t.Errorf("reload of an invalid file succeeded")
+	}
+
+	// Rates of the last valid file are kept.
+	assertRate(t, rates, 0.0200)
+}
+
+func assertRate(t *testing.T, rates *file.Rates, want float64) {
+	t.Helper()
+
+	rate, err := rates.ExchangeRate(context.Background(), "RUB", "USD", date(10))
+	if err != nil {
+		t.Fatalf("get exchange rate: %v", err)
+	}
+
+	if !almostEqual(rate.Rate, want) {
+		t.Errorf("rate is %v, expected %v", rate.Rate, want)
+	}
+}
+
+func almostEqual(a, b float64) bool {
+	const epsilon = 1e-9
+
+	return a-b < epsilon && b-a < epsilon
+}
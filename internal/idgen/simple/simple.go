@@ -1,8 +1,12 @@
 package simple
 
-import "context"
+import (
+	"context"
+	"sync"
+)
 
 type Generator struct {
+	mu      sync.Mutex
 	counter int
 }
 
@@ -12,6 +16,9 @@ func New() *Generator {
 }
 
 func (g *Generator) GetID(_ context.Context) (int, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
 	g.counter++
 
 	return g.counter, nil
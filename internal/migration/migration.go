@@ -6,7 +6,9 @@ import (
 	"time"
 
 	"github.com/avstrong/booking/internal/booking"
+	"github.com/avstrong/booking/internal/boost"
 	"github.com/avstrong/booking/internal/logger"
+	"github.com/avstrong/booking/internal/money"
 )
 
 type storage interface {
@@ -14,13 +16,120 @@ type storage interface {
 	CommitTransaction(ctx context.Context) error
 	RollbackTransaction(ctx context.Context) error
 	SaveRoomAvailabilities(ctx context.Context, availabilities []*booking.RoomAvailability) error
+	GetRoomAvailabilities(ctx context.Context, properties []booking.GetAvailabilityInput) ([]*booking.RoomAvailability, error)
+	ListOrders(ctx context.Context, filter booking.OrderFilter) ([]*booking.Order, error)
+	AppliedMigrations(ctx context.Context) ([]string, error)
+	SaveMigration(ctx context.Context, name string) error
+	GetHotels(ctx context.Context, ids []string) ([]*booking.Hotel, error)
+	SaveHotels(ctx context.Context, hotels []*booking.Hotel) error
+	SaveRoomTypes(ctx context.Context, rooms []*booking.RoomType) error
+	GetRates(ctx context.Context, inputs []booking.GetAvailabilityInput) ([]*booking.RoomRate, error)
+	SaveRates(ctx context.Context, rates []*booking.RoomRate) error
+	GetPromoCodes(ctx context.Context, codes []string) ([]*boost.Promo, error)
+	SavePromoCodes(ctx context.Context, promos []*boost.Promo) error
+}
+
+type migration struct {
+	name string
+	up   func(ctx context.Context, storage storage) error
+}
+
+// migrations are applied in order, each one at most once.
+func migrations() []migration {
+	return []migration{
+		{name: "0001_seed_room_availabilities", up: seedRoomAvailabilities},
+		{name: "0002_release_checkout_nights", up: releaseCheckoutNights},
+		{name: "0003_seed_hotels", up: seedHotels},
+		{name: "0004_seed_rates", up: seedRates},
+		{name: "0005_seed_hotel_taxes", up: seedHotelTaxes},
+		{name: "0006_seed_promo_codes", up: seedPromoCodes},
+		{name: "0007_seed_limited_promo_codes", up: seedLimitedPromoCodes},
+		{name: "0008_set_promo_stacking_rules", up: setPromoStackingRules},
+		{name: "0009_seed_catalog", up: seedCatalog},
+		{name: "0010_set_occupancy_prices", up: setOccupancyPrices},
+	}
 }
 
 func date(year, month, day int) time.Time {
 	return time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
 }
 
-func Up(ctx context.Context, l *logger.Logger, storage storage) (err error) {
+func Up(ctx context.Context, l *logger.Logger, storage storage) error {
+	applied, err := storage.AppliedMigrations(ctx)
+	if err != nil {
+		return fmt.Errorf("get applied migrations: %w", err)
+	}
+
+	done := make(map[string]bool, len(applied))
+	for _, name := range applied {
+		done[name] = true
+	}
+
+	for _, m := range migrations() {
+		if done[m.name] {
+			continue
+		}
+
+		if err := apply(ctx, l, storage, m); err != nil {
+			return fmt.Errorf("apply migration %v: %w", m.name, err)
+		}
+
+		l.LogInfo("Migration %v has been applied", m.name)
+	}
+
+	return nil
+}
+
+func apply(ctx context.Context, l *logger.Logger, storage storage, m migration) (err error) {
+	ctx, err = storage.BeginTransaction(ctx, "")
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			if err = storage.RollbackTransaction(ctx); err != nil {
+				l.LogErrorf("Could not rollback migration transaction after panic %v", p)
+			}
+
+			l.LogInfo("Migration transaction has been roll backed after panic")
+
+			panic(p)
+		}
+
+		if err != nil {
+			if rollbackErr := storage.RollbackTransaction(ctx); rollbackErr != nil {
+				l.LogErrorf("Could not rollback migration transaction after error %v", rollbackErr.Error())
+			}
+
+			l.LogInfo("Migration transaction has been roll backed after error")
+
+			return
+		}
+
+		if err = storage.CommitTransaction(ctx); err != nil {
+			l.LogErrorf("Could not commit migration transaction, err %v", err.Error())
+
+			err = fmt.Errorf("commit transaction: %w", err)
+
+			return
+		}
+
+		l.LogInfo("Migration transaction has been committed")
+	}()
+
+	if err = m.up(ctx, storage); err != nil {
+		return err
+	}
+
+	if err = storage.SaveMigration(ctx, m.name); err != nil {
+		return fmt.Errorf("save migration to storage: %w", err)
+	}
+
+	return nil
+}
+
+func seedRoomAvailabilities(ctx context.Context, storage storage) error {
 	roomAvailabilities := []*booking.RoomAvailability{
 		{
 			HotelID: "reddison",
@@ -55,43 +164,305 @@ func Up(ctx context.Context, l *logger.Logger, storage storage) (err error) {
 		},
 	}
 
-	ctx, err = storage.BeginTransaction(ctx, "")
+	if err := storage.SaveRoomAvailabilities(ctx, roomAvailabilities); err != nil {
+		return fmt.Errorf("save room availabilities to storage: %w", err)
+	}
+
+	return nil
+}
+
+// releaseCheckoutNights returns quota of the checkout night taken by orders which were booked
+// while stays were treated as closed ranges [From, To]. Cancelled and expired orders have
+// already returned all their nights.
+func releaseCheckoutNights(ctx context.Context, storage storage) error {
+	//nolint:exhaustruct
+	orders, err := storage.ListOrders(ctx, booking.OrderFilter{
+		Statuses: []booking.OrderStatus{
+			booking.OrderStatusHeld,
+			booking.OrderStatusPending,
+			booking.OrderStatusConfirmed,
+			booking.OrderStatusCheckedIn,
+			booking.OrderStatusCompleted,
+			booking.OrderStatusNoShow,
+		},
+	})
 	if err != nil {
-		return fmt.Errorf("begin transaction: %w", err)
+		return fmt.Errorf("list orders from storage: %w", err)
 	}
 
-	ctx = booking.NewContextWithIdempotencyKey(ctx, "migration")
+	released := make(map[string]*booking.RoomAvailability)
 
-	defer func() {
-		if p := recover(); p != nil {
-			if err = storage.RollbackTransaction(ctx); err != nil {
-				l.LogErrorf("Could not rollback migration transaction after panic %v", p)
+	for _, order := range orders {
+		for _, place := range order.Places {
+			key := fmt.Sprintf("%s_%s_%s", place.HotelID, place.RoomID, place.To.Format(time.DateOnly))
+			if availability, ok := released[key]; ok {
+				availability.Quota++
+
+				continue
 			}
 
-			l.LogInfo("Migration transaction has been roll backed after panic")
+			availabilities, err := storage.GetRoomAvailabilities(ctx, []booking.GetAvailabilityInput{{
+				HotelID: place.HotelID,
+				RoomID:  place.RoomID,
+				From:    place.To,
+				To:      place.To.AddDate(0, 0, 1),
+			}})
+			if err != nil {
+				return fmt.Errorf("get checkout night availability of order %v: %w", order.ID, err)
+			}
 
-			panic(p)
+			availability := *availabilities[0]
+			availability.Quota++
+
+			released[key] = &availability
 		}
+	}
 
-		if err != nil {
-			if err = storage.RollbackTransaction(ctx); err != nil {
-				l.LogErrorf("Could not rollback migration transaction after error %v", err.Error())
-			}
+	availabilities := make([]*booking.RoomAvailability, 0, len(released))
+	for _, availability := range released {
+		availabilities = append(availabilities, availability)
+	}
 
-			l.LogInfo("Migration transaction has been roll backed after error")
+	if err := storage.SaveRoomAvailabilities(ctx, availabilities); err != nil {
+		return fmt.Errorf("save room availabilities to storage: %w", err)
+	}
 
-			return
+	return nil
+}
+
+func seedHotels(ctx context.Context, storage storage) error {
+	//nolint:exhaustruct
+	hotels := []*booking.Hotel{
+		{
+			ID:           "reddison",
+			TimeZone:     "Europe/Moscow",
+			CheckInTime:  "14:00",
+			CheckOutTime: "12:00",
+		},
+	}
+
+	if err := storage.SaveHotels(ctx, hotels); err != nil {
+		return fmt.Errorf("save hotels to storage: %w", err)
+	}
+
+	return nil
+}
+
+func seedRates(ctx context.Context, storage storage) error {
+	rates := []*booking.RoomRate{
+		{
+			HotelID: "reddison",
+			RoomID:  "lux",
+			Date:    date(2024, 2, 26),
+			Price:   money.FromMajor(5000, "RUB"),
+		},
+		{
+			HotelID: "reddison",
+			RoomID:  "lux",
+			Date:    date(2024, 2, 27),
+			Price:   money.FromMajor(5000, "RUB"),
+		},
+		{
+			HotelID: "reddison",
+			RoomID:  "lux",
+			Date:    date(2024, 2, 28),
+			Price:   money.FromMajor(6500, "RUB"),
+		},
+
+		{
+			HotelID: "reddison",
+			RoomID:  "lux2",
+			Date:    date(2024, 3, 28),
+			Price:   money.FromMajor(7500, "RUB"),
+		},
+		{
+			HotelID: "reddison",
+			RoomID:  "lux2",
+			Date:    date(2024, 3, 29),
+			Price:   money.FromMajor(8000, "RUB"),
+		},
+	}
+
+	if err := storage.SaveRates(ctx, rates); err != nil {
+		return fmt.Errorf("save rates to storage: %w", err)
+	}
+
+	return nil
+}
+
+func seedHotelTaxes(ctx context.Context, storage storage) error {
+	hotels, err := storage.GetHotels(ctx, []string{"reddison"})
+	if err != nil {
+		return fmt.Errorf("get hotels from storage: %w", err)
+	}
+
+	for _, hotel := range hotels {
+		//nolint:exhaustruct
+		hotel.Taxes = []booking.TaxRule{
+			{
+				Code:       "vat",
+				Kind:       booking.TaxKindPercentage,
+				Percentage: 20, //nolint:gomnd // VAT rate
+				Inclusive:  true,
+			},
+			{
+				Code:   "tourist_tax",
+				Kind:   booking.TaxKindPerGuest,
+				Amount: money.FromMajor(100, "RUB"), //nolint:gomnd // per guest and night
+			},
 		}
+	}
 
-		if err = storage.CommitTransaction(ctx); err != nil {
-			l.LogErrorf("Could not commit migration transaction, err %v", err.Error())
+	if err := storage.SaveHotels(ctx, hotels); err != nil {
+		return fmt.Errorf("save hotels to storage: %w", err)
+	}
+
+	return nil
+}
+
+func seedPromoCodes(ctx context.Context, storage storage) error {
+	//nolint:exhaustruct
+	promos := []*boost.Promo{
+		{
+			Code:         "blackFriday",
+			DiscountType: boost.DiscountPercent,
+			Percentage:   35, //nolint:gomnd
+			ValidFrom:    date(2024, 1, 1),
+			ValidThrough: date(2027, 12, 31),
+		},
+		{
+			Code:         "reddisonLongStay",
+			DiscountType: boost.DiscountFixed,
+			Amount:       money.FromMajor(1000, "RUB"), //nolint:gomnd
+			ValidFrom:    date(2024, 1, 1),
+			ValidThrough: date(2027, 12, 31),
+			HotelIDs:     []string{"reddison"},
+			MinNights:    2, //nolint:gomnd
+		},
+	}
+
+	if err := storage.SavePromoCodes(ctx, promos); err != nil {
+		return fmt.Errorf("save promo codes to storage: %w", err)
+	}
+
+	return nil
+}
+
+func seedLimitedPromoCodes(ctx context.Context, storage storage) error {
+	//nolint:exhaustruct
+	promos := []*boost.Promo{
+		{
+			Code:                   "first100",
+			DiscountType:           boost.DiscountPercent,
+			Percentage:             15, //nolint:gomnd
+			ValidFrom:              date(2024, 1, 1),
+			ValidThrough:           date(2027, 12, 31),
+			MaxRedemptions:         100, //nolint:gomnd
+			MaxRedemptionsPerPayer: 1,
+		},
+	}
+
+	if err := storage.SavePromoCodes(ctx, promos); err != nil {
+		return fmt.Errorf("save promo codes to storage: %w", err)
+	}
+
+	return nil
+}
+
+func setPromoStackingRules(ctx context.Context, storage storage) error {
+	promos, err := storage.GetPromoCodes(ctx, []string{"blackFriday", "reddisonLongStay", "first100"})
+	if err != nil {
+		return fmt.Errorf("get promo codes from storage: %w", err)
+	}
+
+	for _, promo := range promos {
+		switch promo.Code {
+		case "blackFriday":
+			promo.Exclusive = true
+		case "reddisonLongStay":
+			promo.Group = "welcome"
+			promo.Priority = 1
+		case "first100":
+			promo.Group = "welcome"
+			promo.MaxDiscountPercentage = 20 //nolint:gomnd
 		}
+	}
 
-		l.LogInfo("Migration transaction has been committed")
-	}()
+	if err := storage.SavePromoCodes(ctx, promos); err != nil {
+		return fmt.Errorf("save promo codes to storage: %w", err)
+	}
 
-	if err = storage.SaveRoomAvailabilities(ctx, roomAvailabilities); err != nil {
-		return fmt.Errorf("save room availabilities to storage: %w", err)
+	return nil
+}
+
+// seedCatalog describes the seeded hotel and its rooms, which were plain ids before the catalog.
+func seedCatalog(ctx context.Context, storage storage) error {
+	hotels, err := storage.GetHotels(ctx, []string{"reddison"})
+	if err != nil {
+		return fmt.Errorf("get hotels from storage: %w", err)
+	}
+
+	for _, hotel := range hotels {
+		hotel.Name = "Reddison"
+		hotel.Address = "Tverskaya st. 1, Moscow"
+		hotel.Amenities = []string{"wifi", "parking", "breakfast"}
+		hotel.Active = true
+	}
+
+	if err := storage.SaveHotels(ctx, hotels); err != nil {
+		return fmt.Errorf("save hotels to storage: %w", err)
+	}
+
+	rooms := []*booking.RoomType{
+		{
+			HotelID:      "reddison",
+			ID:           "lux",
+			Name:         "Lux",
+			MaxOccupancy: 2, //nolint:gomnd
+			Amenities:    []string{"king_bed", "minibar"},
+			Active:       true,
+		},
+		{
+			HotelID:      "reddison",
+			ID:           "lux2",
+			Name:         "Lux with two rooms",
+			MaxOccupancy: 4, //nolint:gomnd
+			Amenities:    []string{"king_bed", "sofa_bed", "minibar"},
+			Active:       true,
+		},
+	}
+
+	if err := storage.SaveRoomTypes(ctx, rooms); err != nil {
+		return fmt.Errorf("save room types to storage: %w", err)
+	}
+
+	return nil
+}
+
+// setOccupancyPrices gives seeded rates a cheaper single occupancy and a price of an extra bed.
+func setOccupancyPrices(ctx context.Context, storage storage) error {
+	rates, err := storage.GetRates(ctx, []booking.GetAvailabilityInput{
+		{HotelID: "reddison", RoomID: "lux", From: date(2024, 2, 26), To: date(2024, 2, 29)},
+		{HotelID: "reddison", RoomID: "lux2", From: date(2024, 3, 28), To: date(2024, 3, 30)},
+	})
+	if err != nil {
+		return fmt.Errorf("get rates from storage: %w", err)
+	}
+
+	for _, rate := range rates {
+		single, err := rate.Price.Sub(money.FromMajor(500, rate.Price.Currency)) //nolint:gomnd
+		if err != nil {
+			return fmt.Errorf("single price of room %v on %v: %w", rate.RoomID, rate.Date.Format(time.DateOnly), err)
+		}
+
+		extraBed := money.FromMajor(1500, rate.Price.Currency) //nolint:gomnd
+
+		rate.SinglePrice = &single
+		rate.ExtraBedPrice = &extraBed
+	}
+
+	if err := storage.SaveRates(ctx, rates); err != nil {
+		return fmt.Errorf("save rates to storage: %w", err)
 	}
 
 	return nil
@@ -0,0 +1,8 @@
+package money
+
+import "errors"
+
+var (
+	ErrCurrencyMismatch = errors.New("currencies do not match")
+	ErrUnknownCurrency  = errors.New("unknown currency")
+)
@@ -0,0 +1,230 @@
+// Package money keeps amounts as integer minor units of an ISO 4217 currency.
+//
+// Amounts are never stored as floats. Operations which can produce a fraction of a minor unit,
+// such as percentages, round half away from zero: 0.5 kopeck becomes 1 kopeck and -0.5 becomes -1.
+package money
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+)
+
+type Money struct {
+	// Amount is in minor units of the currency, e.g. kopecks for RUB.
+	Amount   int64    `json:"amount"`
+	Currency Currency `json:"currency"`
+}
+
+func New(amount int64, currency Currency) Money {
+	return Money{Amount: amount, Currency: currency}
+}
+
+// FromMajor builds money from major units, e.g. 5000 RUB.
+func FromMajor(amount int64, currency Currency) Money {
+	return Money{Amount: amount * currency.factor(), Currency: currency}
+}
+
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+func (m Money) IsNegative() bool {
+	return m.Amount < 0
+}
+
+func (m Money) Add(other Money) (Money, error) {
+	if err := m.sameCurrency(other); err != nil {
+		return Money{}, err
+	}
+
+	return Money{Amount: m.Amount + other.Amount, Currency: m.Currency}, nil
+}
+
+func (m Money) Sub(other Money) (Money, error) {
+	if err := m.sameCurrency(other); err != nil {
+		return Money{}, err
+	}
+
+	return Money{Amount: m.Amount - other.Amount, Currency: m.Currency}, nil
+}
+
+func (m Money) Neg() Money {
+	return Money{Amount: -m.Amount, Currency: m.Currency}
+}
+
+// Mul multiplies the amount by a whole number, e.g. by a number of nights.
+func (m Money) Mul(n int64) Money {
+	return Money{Amount: m.Amount * n, Currency: m.Currency}
+}
+
+// Percent returns the given percentage of the amount. The percentage is taken with a precision
+// of a hundredth of a percent and the result is rounded half away from zero.
+func (m Money) Percent(percent float64) Money {
+	basisPoints := int64(math.Round(percent * 100)) //nolint:gomnd // hundredths of a percent
+
+	return Money{Amount: divRound(m.Amount*basisPoints, 10000), Currency: m.Currency} //nolint:gomnd // 100% in basis points
+}
+
+// IncludedPercent returns the part of the amount which is a tax of the given percentage already included
+// in it, e.g. 20 RUB of 120 RUB for 20%. The result is rounded half away from zero.
+func (m Money) IncludedPercent(percent float64) Money {
+	basisPoints := int64(math.Round(percent * 100)) //nolint:gomnd // hundredths of a percent
+
+	return Money{Amount: divRound(m.Amount*basisPoints, 10000+basisPoints), Currency: m.Currency} //nolint:gomnd // 100% in basis points
+}
+
+// Ratio returns the numerator/denominator share of the amount rounded half away from zero,
+// e.g. to split an amount between parts of an order.
+func (m Money) Ratio(numerator, denominator int64) Money {
+	if denominator == 0 {
+		return Money{Amount: 0, Currency: m.Currency}
+	}
+
+	return Money{Amount: divRound(m.Amount*numerator, denominator), Currency: m.Currency}
+}
+
+// Convert converts the amount into another currency by the rate, which is the price of one major unit
+// of the amount's currency in major units of the target one. The result is rounded half away from zero.
+func (m Money) Convert(to Currency, rate float64) Money {
+	value := new(big.Rat).SetInt64(m.Amount)
+	value.Mul(value, new(big.Rat).SetFloat64(rate))
+	value.Mul(value, new(big.Rat).SetFrac64(to.factor(), m.Currency.factor()))
+
+	quotient, remainder := new(big.Int).QuoRem(value.Num(), value.Denom(), new(big.Int))
+	if new(big.Int).Mul(new(big.Int).Abs(remainder), big.NewInt(2)).Cmp(value.Denom()) >= 0 { //nolint:gomnd // a half
+		quotient.Add(quotient, big.NewInt(int64(value.Sign())))
+	}
+
+	return Money{Amount: quotient.Int64(), Currency: to}
+}
+
+// Min returns the smaller of two amounts in the same currency.
+func (m Money) Min(other Money) (Money, error) {
+	if err := m.sameCurrency(other); err != nil {
+		return Money{}, err
+	}
+
+	if other.Amount < m.Amount {
+		return other, nil
+	}
+
+	return m, nil
+}
+
+// String formats the amount in major units, e.g. "5000.00 RUB".
+func (m Money) String() string {
+	exponent := m.Currency.Exponent()
+	if exponent == 0 {
+		return fmt.Sprintf("%d %s", m.Amount, m.Currency)
+	}
+
+	sign := ""
+	amount := m.Amount
+
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+
+	factor := m.Currency.factor()
+
+	return fmt.Sprintf("%s%d.%0*d %s", sign, amount/factor, exponent, amount%factor, m.Currency)
+}
+
+func (m Money) sameCurrency(other Money) error {
+	if m.Currency != other.Currency {
+		return fmt.Errorf("%v and %v: %w", m.Currency, other.Currency, ErrCurrencyMismatch)
+	}
+
+	return nil
+}
+
+// Sum adds up amounts in the same currency. The sum of nothing is the zero value.
+func Sum(amounts ...Money) (Money, error) {
+	if len(amounts) == 0 {
+		return Money{}, nil
+	}
+
+	total := Money{Amount: 0, Currency: amounts[0].Currency}
+
+	for _, amount := range amounts {
+		var err error
+
+		if total, err = total.Add(amount); err != nil {
+			return Money{}, err
+		}
+	}
+
+	return total, nil
+}
+
+// divRound divides rounding half away from zero.
+func divRound(numerator, denominator int64) int64 {
+	quotient := numerator / denominator
+	remainder := numerator % denominator
+
+	if remainder < 0 {
+		remainder = -remainder
+	}
+
+	if remainder*2 >= denominator {
+		if numerator < 0 {
+			return quotient - 1
+		}
+
+		return quotient + 1
+	}
+
+	return quotient
+}
+
+// Currency is an ISO 4217 alphabetic code.
+type Currency string
+
+// ParseCurrency returns a supported currency by its code in any case.
+func ParseCurrency(code string) (Currency, error) {
+	currency := Currency(strings.ToUpper(strings.TrimSpace(code)))
+	if !currency.Valid() {
+		return "", fmt.Errorf("%q: %w", code, ErrUnknownCurrency)
+	}
+
+	return currency, nil
+}
+
+func (c Currency) Valid() bool {
+	_, ok := c.exponent()
+
+	return ok
+}
+
+// Exponent returns the number of digits of minor units, e.g. 2 for RUB and 0 for JPY.
+func (c Currency) Exponent() int {
+	exponent, _ := c.exponent()
+
+	return exponent
+}
+
+func (c Currency) exponent() (int, bool) {
+	switch c {
+	case "RUB", "USD", "EUR", "GBP", "CNY", "CHF", "KZT", "BYN", "AMD", "GEL", "TRY", "AED", "UZS":
+		return 2, true //nolint:gomnd // ISO 4217 exponent
+	case "JPY", "KRW":
+		return 0, true
+	case "KWD", "BHD", "OMR":
+		return 3, true //nolint:gomnd // ISO 4217 exponent
+	default:
+		return 0, false
+	}
+}
+
+func (c Currency) factor() int64 {
+	factor := int64(1)
+
+	for i := 0; i < c.Exponent(); i++ {
+		factor *= 10 //nolint:gomnd // decimal
+	}
+
+	return factor
+}
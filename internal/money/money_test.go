@@ -0,0 +1,191 @@
+package money_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/avstrong/booking/internal/money"
+)
+
+func TestMoney_Percent(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		amount  money.Money
+		percent float64
+		want    int64
+	}{
+		{name: "exact", amount: money.New(12000, "RUB"), percent: 20, want: 2400},
+		{name: "half rounds up", amount: money.New(1005, "RUB"), percent: 10, want: 101},
+		{name: "negative half rounds down", amount: money.New(-1005, "RUB"), percent: 10, want: -101},
+		{name: "below half", amount: money.New(1004, "RUB"), percent: 10, want: 100},
+		{name: "negative below half", amount: money.New(-1004, "RUB"), percent: 10, want: -100},
+		{name: "fractional percent", amount: money.New(999, "RUB"), percent: 12.5, want: 125},
+		{name: "exponent 0", amount: money.New(155, "JPY"), percent: 10, want: 16},
+		{name: "exponent 3", amount: money.FromMajor(1, "KWD"), percent: 0.05, want: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := tt.amount.Percent(tt.percent)
+			if got.Amount != tt.want || got.Currency != tt.amount.Currency {
+				t.Errorf("%v%% of %v is %v, expected %v", tt.percent, tt.amount, got, money.New(tt.want, tt.amount.Currency))
+			}
+		})
+	}
+}
+
+func TestMoney_IncludedPercent(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		amount  money.Money
+		percent float64
+		want    int64
+	}{
+		{name: "exact", amount: money.New(12000, "RUB"), percent: 20, want: 2000},
+		{name: "rounds up", amount: money.New(100, "RUB"), percent: 20, want: 17},
+		{name: "negative rounds down", amount: money.New(-100, "RUB"), percent: 20, want: -17},
+		{name: "exponent 0", amount: money.New(110, "JPY"), percent: 10, want: 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := tt.amount.IncludedPercent(tt.percent)
+			if got.Amount != tt.want {
+				t.Errorf("%v%% included in %v is %v, expected %v", tt.percent, tt.amount, got, money.New(tt.want, tt.amount.Currency))
+			}
+		})
+	}
+}
+
+func TestMoney_Ratio(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		amount      money.Money
+		numerator   int64
+		denominator int64
+		want        int64
+	}{
+		{name: "smaller share", amount: money.New(1000, "RUB"), numerator: 1, denominator: 3, want: 333},
+		{name: "larger share", amount: money.New(1000, "RUB"), numerator: 2, denominator: 3, want: 667},
+		{name: "negative share", amount: money.New(-1000, "RUB"), numerator: 2, denominator: 3, want: -667},
+		{name: "half", amount: money.New(5, "RUB"), numerator: 1, denominator: 2, want: 3},
+		{name: "negative half", amount: money.New(-5, "RUB"), numerator: 1, denominator: 2, want: -3},
+		{name: "zero denominator", amount: money.New(1000, "RUB"), numerator: 1, denominator: 0, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := tt.amount.Ratio(tt.numerator, tt.denominator)
+			if got.Amount != tt.want {
+				t.Errorf("%v/%v of %v is %v, expected %v", tt.numerator, tt.denominator, tt.amount, got, money.New(tt.want, tt.amount.Currency))
+			}
+		})
+	}
+}
+
+func TestMoney_RatioSplitsWholeAmount(t *testing.T) {
+	t.Parallel()
+
+	// Shares of 1000 in proportion 1:2 add up to the whole amount.
+	total := money.New(1000, "RUB")
+
+	sum, err := money.Sum(total.Ratio(1, 3), total.Ratio(2, 3))
+	if err != nil {
+		t.Fatalf("sum shares: %v", err)
+	}
+
+	if sum != total {
+		t.Errorf("shares add up to %v, expected %v", sum, total)
+	}
+}
+
+func TestMoney_Convert(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		amount money.Money
+		to     money.Currency
+		rate   float64
+		want   int64
+	}{
+		{name: "same exponent", amount: money.New(10000, "USD"), to: "RUB", rate: 90.5, want: 905000},
+		{name: "to exponent 0", amount: money.New(150, "USD"), to: "JPY", rate: 150, want: 225},
+		{name: "to exponent 0 half", amount: money.New(101, "USD"), to: "JPY", rate: 1.5, want: 2},
+		{name: "negative half", amount: money.New(-150, "USD"), to: "JPY", rate: 1, want: -2},
+		{name: "from exponent 0", amount: money.New(1000, "JPY"), to: "USD", rate: 0.0067, want: 670},
+		{name: "from exponent 3 below half", amount: money.New(1, "KWD"), to: "USD", rate: 3.25, want: 0},
+		{name: "from exponent 3 above half", amount: money.New(2, "KWD"), to: "USD", rate: 3.25, want: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := tt.amount.Convert(tt.to, tt.rate)
+			if got.Amount != tt.want || got.Currency != tt.to {
+				t.Errorf("%v at %v is %v, expected %v", tt.amount, tt.rate, got, money.New(tt.want, tt.to))
+			}
+		})
+	}
+}
+
+func TestMoney_String(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		amount money.Money
+		want   string
+	}{
+		{amount: money.FromMajor(5000, "RUB"), want: "5000.00 RUB"},
+		{amount: money.New(-5, "RUB"), want: "-0.05 RUB"},
+		{amount: money.New(500, "JPY"), want: "500 JPY"},
+		{amount: money.New(-500, "JPY"), want: "-500 JPY"},
+		{amount: money.New(1234, "KWD"), want: "1.234 KWD"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.amount.String(); got != tt.want {
+			t.Errorf("%#v is formatted as %q, expected %q", tt.amount, got, tt.want)
+		}
+	}
+}
+
+func TestMoney_MixedCurrencies(t *testing.T) {
+	t.Parallel()
+
+	rub := money.FromMajor(100, "RUB")
+	usd := money.FromMajor(1, "USD")
+
+	tests := []struct {
+		name string
+		op   func() (money.Money, error)
+	}{
+		{name: "add", op: func() (money.Money, error) { return rub.Add(usd) }},
+		{name: "sub", op: func() (money.Money, error) { return rub.Sub(usd) }},
+		{name: "min", op: func() (money.Money, error) { return rub.Min(usd) }},
+		{name: "sum", op: func() (money.Money, error) { return money.Sum(rub, rub, usd) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if _, err := tt.op(); !errors.Is(err, money.ErrCurrencyMismatch) {
+				t.Errorf("got %v, expected %v", err, money.ErrCurrencyMismatch)
+			}
+		})
+	}
+}
@@ -3,10 +3,13 @@ package memory
 import (
 	"context"
 	"fmt"
+	"slices"
+	"sort"
 	"sync"
 	"time"
 
 	"github.com/avstrong/booking/internal/booking"
+	"github.com/avstrong/booking/internal/boost"
 	"github.com/avstrong/booking/internal/logger"
 )
 
@@ -15,33 +18,72 @@ type Config struct {
 }
 
 type transaction struct {
-	id                 string
-	roomModifications  map[string]*booking.RoomAvailability
-	orderModifications map[int]*booking.Order
-	eventModifications map[int]*booking.Event
-	rollbackActions    []func()
+	id                       string
+	roomModifications        map[string]*booking.RoomAvailability
+	orderModifications       map[int]*booking.Order
+	eventModifications       map[int]*booking.Event
+	hotelModifications       map[string]*booking.Hotel
+	roomTypeModifications    map[string]*booking.RoomType
+	rateModifications        map[string]*booking.RoomRate
+	restrictionModifications map[string]*booking.StayRestriction
+	promoModifications       map[string]*boost.Promo
+	quotaChanges             []booking.QuotaChange
+	redemptions              []booking.PromoRedemption
+	releasedPromoUses        []int
+	idempotentResults        map[string]*booking.Order
+	migrations               []string
+}
+
+func availabilityKey(hotelID, roomID string, date time.Time) string {
+	return fmt.Sprintf("%s_%s_%s", hotelID, roomID, date.Format(time.RFC3339))
+}
+
+func cloneOrder(order *booking.Order) *booking.Order {
+	clone := *order
+	clone.Places = append([]booking.Place(nil), order.Places...)
+	for idx := range clone.Places {
+		clone.Places[idx].Guests = append([]booking.Guest(nil), order.Places[idx].Guests...)
+	}
+	clone.LineItems = append([]booking.LineItem(nil), order.LineItems...)
+
+	return &clone
 }
 
 type DB struct {
-	mu                   sync.Mutex
-	l                    *logger.Logger
-	roomAvailabilities   map[string]*booking.RoomAvailability
-	events               map[int]*booking.Event
-	orders               map[int]*booking.Order
-	transactions         map[string]*transaction
-	nextTrxID            int64
-	orderIdempotencyKeys map[string]*booking.Order
+	mu                 sync.Mutex
+	l                  *logger.Logger
+	roomAvailabilities map[string]*booking.RoomAvailability
+	events             map[int]*booking.Event
+	orders             map[int]*booking.Order
+	transactions       map[string]*transaction
+	nextTrxID          int64
+	idempotencyKeys    map[string]*booking.IdempotencyRecord
+	migrations         map[string]time.Time
+	hotels             map[string]*booking.Hotel
+	roomTypes          map[string]*booking.RoomType
+	rates              map[string]*booking.RoomRate
+	restrictions       map[string]*booking.StayRestriction
+	promoCodes         map[string]*boost.Promo
+	// redemptions are usages of promo codes by order ids.
+	redemptions map[int][]booking.PromoRedemption
 }
 
 func New(conf Config) *DB {
 	//nolint:exhaustruct
 	return &DB{
-		l:                    conf.L,
-		roomAvailabilities:   make(map[string]*booking.RoomAvailability),
-		events:               make(map[int]*booking.Event),
-		orders:               make(map[int]*booking.Order),
-		transactions:         make(map[string]*transaction),
-		orderIdempotencyKeys: make(map[string]*booking.Order),
+		l:                  conf.L,
+		roomAvailabilities: make(map[string]*booking.RoomAvailability),
+		events:             make(map[int]*booking.Event),
+		orders:             make(map[int]*booking.Order),
+		transactions:       make(map[string]*transaction),
+		idempotencyKeys:    make(map[string]*booking.IdempotencyRecord),
+		migrations:         make(map[string]time.Time),
+		hotels:             make(map[string]*booking.Hotel),
+		roomTypes:          make(map[string]*booking.RoomType),
+		rates:              make(map[string]*booking.RoomRate),
+		restrictions:       make(map[string]*booking.StayRestriction),
+		promoCodes:         make(map[string]*boost.Promo),
+		redemptions:        make(map[int][]booking.PromoRedemption),
 	}
 }
 
@@ -53,11 +95,18 @@ func (db *DB) BeginTransaction(ctx context.Context, _ string) (context.Context,
 	db.nextTrxID++
 
 	db.transactions[trxID] = &transaction{
-		id:                 trxID,
-		roomModifications:  make(map[string]*booking.RoomAvailability),
-		orderModifications: make(map[int]*booking.Order),
-		eventModifications: make(map[int]*booking.Event),
-		rollbackActions:    []func(){},
+		id:                       trxID,
+		roomModifications:        make(map[string]*booking.RoomAvailability),
+		orderModifications:       make(map[int]*booking.Order),
+		eventModifications:       make(map[int]*booking.Event),
+		hotelModifications:       make(map[string]*booking.Hotel),
+		roomTypeModifications:    make(map[string]*booking.RoomType),
+		rateModifications:        make(map[string]*booking.RoomRate),
+		restrictionModifications: make(map[string]*booking.StayRestriction),
+		promoModifications:       make(map[string]*boost.Promo),
+		quotaChanges:             nil,
+		idempotentResults:        make(map[string]*booking.Order),
+		migrations:               nil,
 	}
 
 	return withTransactionID(ctx, trxID), nil
@@ -77,29 +126,204 @@ func (db *DB) CommitTransaction(ctx context.Context) error {
 		return fmt.Errorf("transaction %s not found: %w", trxID, ErrTransactionNotFound)
 	}
 
-	idempotencyKey, ok := booking.IdempotencyKeyFromContext(ctx)
-	if !ok || idempotencyKey == "" {
-		return booking.ErrIdempotencyKey
+	// Nothing is applied unless all checks pass, so a failed commit leaves storage untouched.
+	quotas, err := db.checkQuotaChanges(trx)
+	if err == nil {
+		err = db.checkOrderVersions(trx)
+	}
+
+	if err == nil {
+		err = db.checkRedemptions(trx)
+	}
+
+	if err != nil {
+		delete(db.transactions, trxID)
+
+		return err
 	}
 
 	for key, room := range trx.roomModifications {
 		db.roomAvailabilities[key] = room
 	}
 
+	for key, room := range quotas {
+		db.roomAvailabilities[key] = room
+	}
+
 	for _, order := range trx.orderModifications {
 		db.orders[order.ID] = order
-		db.orderIdempotencyKeys[idempotencyKey] = order
+	}
+
+	for key, order := range trx.idempotentResults {
+		if record, ok := db.idempotencyKeys[key]; ok {
+			record.Status = booking.IdempotencyCompleted
+			record.Order = order
+			record.UpdatedAt = time.Now().UTC()
+		}
 	}
 
 	for _, event := range trx.eventModifications {
 		db.events[event.ID] = event
 	}
 
+	for id, hotel := range trx.hotelModifications {
+		db.hotels[id] = hotel
+	}
+
+	for key, room := range trx.roomTypeModifications {
+		db.roomTypes[key] = room
+	}
+
+	for key, rate := range trx.rateModifications {
+		db.rates[key] = rate
+	}
+
+	for key, restriction := range trx.restrictionModifications {
+		db.restrictions[key] = restriction
+	}
+
+	for code, promo := range trx.promoModifications {
+		db.promoCodes[code] = promo
+	}
+
+	for _, orderID := range trx.releasedPromoUses {
+		delete(db.redemptions, orderID)
+	}
+
+	for _, redemption := range trx.redemptions {
+		db.redemptions[redemption.OrderID] = append(db.redemptions[redemption.OrderID], redemption)
+	}
+
+	for _, name := range trx.migrations {
+		db.migrations[name] = time.Now().UTC()
+	}
+
 	delete(db.transactions, trxID)
 
 	return nil
 }
 
+// checkQuotaChanges applies quota changes of the transaction to copies of the current availabilities.
+// It fails with booking.ErrConflict if any quota would become negative or is not the expected one.
+func (db *DB) checkQuotaChanges(trx *transaction) (map[string]*booking.RoomAvailability, error) {
+	quotas := make(map[string]*booking.RoomAvailability)
+
+	for _, change := range trx.quotaChanges {
+		key := availabilityKey(change.HotelID, change.RoomID, change.Date)
+
+		room, ok := quotas[key]
+		if !ok {
+			current, exists := trx.roomModifications[key]
+			if !exists {
+				current, exists = db.roomAvailabilities[key]
+			}
+
+			// A night which is not on sale yet has no quota. It is created here rather than by the caller, so a stale
+			// read can not overwrite a night created concurrently.
+			if !exists {
+				current = &booking.RoomAvailability{HotelID: change.HotelID, RoomID: change.RoomID, Date: change.Date, Quota: 0}
+			}
+
+			clone := *current
+			room = &clone
+			quotas[key] = room
+		}
+
+		if change.Expected != nil && *change.Expected != room.Quota {
+			return nil, fmt.Errorf(
+				"quota of room %v in hotel %v on %v is %v, expected %v: %w",
+				room.RoomID,
+				room.HotelID,
+				room.Date.Format(time.DateOnly),
+				room.Quota,
+				*change.Expected,
+				booking.ErrConflict,
+			)
+		}
+
+		room.Quota += change.Delta
+	}
+
+	for _, room := range quotas {
+		if room.Quota < 0 {
+			return nil, fmt.Errorf(
+				"quota of room %v in hotel %v on %v is exhausted: %w",
+				room.RoomID,
+				room.HotelID,
+				room.Date.Format(time.DateOnly),
+				booking.ErrConflict,
+			)
+		}
+	}
+
+	return quotas, nil
+}
+
+// checkRedemptions makes sure promo codes redeemed in the transaction stay within their limits.
+func (db *DB) checkRedemptions(trx *transaction) error {
+	if len(trx.redemptions) == 0 {
+		return nil
+	}
+
+	released := make(map[int]bool, len(trx.releasedPromoUses))
+	for _, orderID := range trx.releasedPromoUses {
+		released[orderID] = true
+	}
+
+	total := make(map[string]int)
+	byPayer := make(map[string]int)
+
+	count := func(redemption booking.PromoRedemption) {
+		total[redemption.Code]++
+		byPayer[redemption.Code+"_"+redemption.PayerEmail]++
+	}
+
+	for orderID, redemptions := range db.redemptions {
+		if !released[orderID] {
+			for _, redemption := range redemptions {
+				count(redemption)
+			}
+		}
+	}
+
+	for _, redemption := range trx.redemptions {
+		count(redemption)
+	}
+
+	for _, redemption := range trx.redemptions {
+		promo, ok := db.promoCodes[redemption.Code]
+		if !ok {
+			return fmt.Errorf("promo code %v: %w", redemption.Code, booking.ErrRecordNotFound)
+		}
+
+		if promo.MaxRedemptions > 0 && total[redemption.Code] > promo.MaxRedemptions {
+			return &booking.PromoLimitError{Code: redemption.Code, PerPayer: false}
+		}
+
+		if promo.MaxRedemptionsPerPayer > 0 && byPayer[redemption.Code+"_"+redemption.PayerEmail] > promo.MaxRedemptionsPerPayer {
+			return &booking.PromoLimitError{Code: redemption.Code, PerPayer: true}
+		}
+	}
+
+	return nil
+}
+
+// checkOrderVersions makes sure orders of the transaction are saved over the versions they were read at.
+func (db *DB) checkOrderVersions(trx *transaction) error {
+	for id, order := range trx.orderModifications {
+		var storedVersion int
+		if stored, ok := db.orders[id]; ok {
+			storedVersion = stored.Version
+		}
+
+		if storedVersion != order.Version-1 {
+			return fmt.Errorf("order %v has been changed concurrently: %w", id, booking.ErrConflict)
+		}
+	}
+
+	return nil
+}
+
 func (db *DB) RollbackTransaction(ctx context.Context) error {
 	db.mu.Lock()
 	defer db.mu.Unlock()
@@ -109,15 +333,11 @@ func (db *DB) RollbackTransaction(ctx context.Context) error {
 		return ErrTransactionIDNotFoundInCtx
 	}
 
-	trx, exists := db.transactions[trxID]
-	if !exists {
+	if _, exists := db.transactions[trxID]; !exists {
 		return fmt.Errorf("transaction %s not found: %w", trxID, ErrTransactionNotFound)
 	}
 
-	for _, action := range trx.rollbackActions {
-		action()
-	}
-
+	// Modifications are staged in the transaction until commit, so discarding it leaves committed data intact.
 	delete(db.transactions, trxID)
 
 	return nil
@@ -138,27 +358,29 @@ func (db *DB) SaveRoomAvailabilities(ctx context.Context, availabilities []*book
 	}
 
 	for _, availability := range availabilities {
-		key := fmt.Sprintf("%s_%s_%s", availability.HotelID, availability.RoomID, availability.Date.Format(time.RFC3339))
-		if _, ok := trx.roomModifications[key]; ok {
-			continue
-		}
-
+		key := availabilityKey(availability.HotelID, availability.RoomID, availability.Date)
 		trx.roomModifications[key] = availability
+	}
+
+	return nil
+}
 
-		originalRoom, exists := db.roomAvailabilities[key]
-		if exists {
-			trx.rollbackActions = append(trx.rollbackActions, func() {
-				db.roomAvailabilities[key] = originalRoom
-			})
+func (db *DB) AdjustQuotas(ctx context.Context, changes []booking.QuotaChange) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
 
-			continue
-		}
+	trxID, ok := transactionIDFromContext(ctx)
+	if !ok || trxID == "" {
+		return ErrTransactionIDNotFoundInCtx
+	}
 
-		trx.rollbackActions = append(trx.rollbackActions, func() {
-			delete(db.roomAvailabilities, key)
-		})
+	trx, exists := db.transactions[trxID]
+	if !exists {
+		return fmt.Errorf("transaction %s not found: %w", trxID, ErrTransactionNotFound)
 	}
 
+	trx.quotaChanges = append(trx.quotaChanges, changes...)
+
 	return nil
 }
 
@@ -176,14 +398,9 @@ func (db *DB) SaveOrder(ctx context.Context, order *booking.Order) error {
 		return fmt.Errorf("transaction %s not found: %w", trxID, ErrTransactionNotFound)
 	}
 
-	if _, ok = trx.orderModifications[order.ID]; ok {
-		return nil
-	}
-
-	trx.orderModifications[order.ID] = order
-	trx.rollbackActions = append(trx.rollbackActions, func() {
-		delete(db.orders, order.ID)
-	})
+	// The order is staged until commit, so a rollback has nothing to undo in committed orders. The latest
+	// save within the transaction wins.
+	trx.orderModifications[order.ID] = cloneOrder(order)
 
 	return nil
 }
@@ -207,9 +424,6 @@ func (db *DB) SaveEvent(ctx context.Context, event *booking.Event) error {
 	}
 
 	trx.eventModifications[event.ID] = event
-	trx.rollbackActions = append(trx.rollbackActions, func() {
-		delete(db.events, event.ID)
-	})
 
 	return nil
 }
@@ -225,8 +439,8 @@ func (db *DB) GetAvailabilities(_ context.Context, inputs []booking.GetAvailabil
 	var result []*booking.RoomAvailability
 
 	for _, input := range inputs {
-		for d := input.From; !d.After(input.To); d = d.AddDate(0, 0, 1) {
-			key := fmt.Sprintf("%s_%s_%s", input.HotelID, input.RoomID, d.Format(time.RFC3339))
+		for d := input.From; d.Before(input.To); d = d.AddDate(0, 0, 1) {
+			key := availabilityKey(input.HotelID, input.RoomID, d)
 
 			roomAvailability, ok := db.roomAvailabilities[key]
 			if !ok || roomAvailability.Quota < 1 {
@@ -251,19 +465,524 @@ func (db *DB) GetAvailabilities(_ context.Context, inputs []booking.GetAvailabil
 	return result, nil
 }
 
-func (db *DB) GetOrderByIdempotencyKey(ctx context.Context) (*booking.Order, error) {
+func (db *DB) GetOrder(_ context.Context, id int) (*booking.Order, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	order, exists := db.orders[id]
+	if !exists {
+		return nil, fmt.Errorf("order %v: %w", id, booking.ErrRecordNotFound)
+	}
+
+	return cloneOrder(order), nil
+}
+
+// ListOrders returns orders matching the filter in ascending id order.
+func (db *DB) ListOrders(_ context.Context, filter booking.OrderFilter) ([]*booking.Order, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	ids := make([]int, 0, len(db.orders))
+
+	for id := range db.orders {
+		if id > filter.AfterID {
+			ids = append(ids, id)
+		}
+	}
+
+	sort.Ints(ids)
+
+	var result []*booking.Order
+
+	for _, id := range ids {
+		order := db.orders[id]
+		if !filter.Match(order) {
+			continue
+		}
+
+		result = append(result, cloneOrder(order))
+
+		if filter.Limit > 0 && len(result) == filter.Limit {
+			break
+		}
+	}
+
+	return result, nil
+}
+
+// GetRoomAvailabilities returns stored availabilities for every night of inputs regardless of their quota.
+func (db *DB) GetRoomAvailabilities(
+	_ context.Context,
+	inputs []booking.GetAvailabilityInput,
+) ([]*booking.RoomAvailability, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	var result []*booking.RoomAvailability
+
+	for _, input := range inputs {
+		for d := input.From; d.Before(input.To); d = d.AddDate(0, 0, 1) {
+			roomAvailability, ok := db.roomAvailabilities[availabilityKey(input.HotelID, input.RoomID, d)]
+			if !ok {
+				return nil, fmt.Errorf(
+					"room %v in hotel %v on %v: %w",
+					input.RoomID,
+					input.HotelID,
+					d.Format(time.DateOnly),
+					booking.ErrRecordNotFound,
+				)
+			}
+
+			result = append(result, roomAvailability)
+		}
+	}
+
+	return result, nil
+}
+
+// SearchAvailabilities returns stored availabilities matching the filter sorted by hotel, room and date.
+// Unlike GetAvailabilities, missing nights and nights without quota are not an error.
+func (db *DB) SearchAvailabilities(
+	_ context.Context,
+	filter booking.AvailabilityFilter,
+) ([]*booking.RoomAvailability, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	var result []*booking.RoomAvailability
+
+	for _, roomAvailability := range db.roomAvailabilities {
+		if !slices.Contains(filter.HotelIDs, roomAvailability.HotelID) {
+			continue
+		}
+
+		if len(filter.RoomIDs) > 0 && !slices.Contains(filter.RoomIDs, roomAvailability.RoomID) {
+			continue
+		}
+
+		if roomAvailability.Date.Before(filter.From) || !roomAvailability.Date.Before(filter.To) {
+			continue
+		}
+
+		clone := *roomAvailability
+		result = append(result, &clone)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].HotelID != result[j].HotelID {
+			return result[i].HotelID < result[j].HotelID
+		}
+
+		if result[i].RoomID != result[j].RoomID {
+			return result[i].RoomID < result[j].RoomID
+		}
+
+		return result[i].Date.Before(result[j].Date)
+	})
+
+	return result, nil
+}
+
+func (db *DB) AppliedMigrations(_ context.Context) ([]string, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	names := make([]string, 0, len(db.migrations))
+	for name := range db.migrations {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names, nil
+}
+
+func (db *DB) SaveMigration(ctx context.Context, name string) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	trxID, ok := transactionIDFromContext(ctx)
+	if !ok || trxID == "" {
+		return ErrTransactionIDNotFoundInCtx
+	}
+
+	trx, exists := db.transactions[trxID]
+	if !exists {
+		return fmt.Errorf("transaction %s not found: %w", trxID, ErrTransactionNotFound)
+	}
+
+	trx.migrations = append(trx.migrations, name)
+
+	return nil
+}
+
+// GetHotels returns settings of the hotels which exist in storage.
+func (db *DB) GetHotels(_ context.Context, ids []string) ([]*booking.Hotel, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	result := make([]*booking.Hotel, 0, len(ids))
+
+	for _, id := range ids {
+		if hotel, ok := db.hotels[id]; ok {
+			result = append(result, cloneHotel(hotel))
+		}
+	}
+
+	return result, nil
+}
+
+func (db *DB) SaveHotels(ctx context.Context, hotels []*booking.Hotel) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	trxID, ok := transactionIDFromContext(ctx)
+	if !ok || trxID == "" {
+		return ErrTransactionIDNotFoundInCtx
+	}
+
+	trx, exists := db.transactions[trxID]
+	if !exists {
+		return fmt.Errorf("transaction %s not found: %w", trxID, ErrTransactionNotFound)
+	}
+
+	for _, hotel := range hotels {
+		trx.hotelModifications[hotel.ID] = cloneHotel(hotel)
+	}
+
+	return nil
+}
+
+func cloneHotel(hotel *booking.Hotel) *booking.Hotel {
+	clone := *hotel
+	clone.Taxes = append([]booking.TaxRule(nil), hotel.Taxes...)
+	clone.Amenities = append([]string(nil), hotel.Amenities...)
+
+	return &clone
+}
+
+// ListHotels returns all hotels sorted by id.
+func (db *DB) ListHotels(_ context.Context) ([]*booking.Hotel, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	result := make([]*booking.Hotel, 0, len(db.hotels))
+	for _, hotel := range db.hotels {
+		result = append(result, cloneHotel(hotel))
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ID < result[j].ID
+	})
+
+	return result, nil
+}
+
+func roomTypeKey(hotelID, roomID string) string {
+	return fmt.Sprintf("%s_%s", hotelID, roomID)
+}
+
+func cloneRoomType(room *booking.RoomType) *booking.RoomType {
+	clone := *room
+	clone.Amenities = append([]string(nil), room.Amenities...)
+
+	return &clone
+}
+
+// GetRoomTypes returns room types of the hotels sorted by hotel and room id.
+func (db *DB) GetRoomTypes(_ context.Context, hotelIDs []string) ([]*booking.RoomType, error) {
 	db.mu.Lock()
 	defer db.mu.Unlock()
 
-	key, ok := booking.IdempotencyKeyFromContext(ctx)
-	if !ok || key == "" {
-		return nil, booking.ErrIdempotencyKey
+	var result []*booking.RoomType
+
+	for _, room := range db.roomTypes {
+		if slices.Contains(hotelIDs, room.HotelID) {
+			result = append(result, cloneRoomType(room))
+		}
 	}
 
-	order, exists := db.orderIdempotencyKeys[key]
-	if exists {
-		return order, nil
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].HotelID != result[j].HotelID {
+			return result[i].HotelID < result[j].HotelID
+		}
+
+		return result[i].ID < result[j].ID
+	})
+
+	return result, nil
+}
+
+func (db *DB) SaveRoomTypes(ctx context.Context, rooms []*booking.RoomType) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	trxID, ok := transactionIDFromContext(ctx)
+	if !ok || trxID == "" {
+		return ErrTransactionIDNotFoundInCtx
+	}
+
+	trx, exists := db.transactions[trxID]
+	if !exists {
+		return fmt.Errorf("transaction %s not found: %w", trxID, ErrTransactionNotFound)
+	}
+
+	for _, room := range rooms {
+		trx.roomTypeModifications[roomTypeKey(room.HotelID, room.ID)] = cloneRoomType(room)
+	}
+
+	return nil
+}
+
+func cloneIdempotencyRecord(record *booking.IdempotencyRecord) *booking.IdempotencyRecord {
+	clone := *record
+	if record.Order != nil {
+		clone.Order = cloneOrder(record.Order)
+	}
+
+	return &clone
+}
+
+func (db *DB) ReserveIdempotencyKey(
+	_ context.Context,
+	candidate *booking.IdempotencyRecord,
+) (*booking.IdempotencyRecord, bool, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	record, exists := db.idempotencyKeys[candidate.Key]
+	if exists && record.ExpiresAt.After(candidate.CreatedAt) &&
+		(record.Status != booking.IdempotencyFailed || record.Scope != candidate.Scope) {
+		return cloneIdempotencyRecord(record), false, nil
 	}
 
-	return nil, booking.ErrRecordNotFound
+	reserved := cloneIdempotencyRecord(candidate)
+	reserved.Status = booking.IdempotencyInProgress
+
+	if exists && record.ExpiresAt.After(candidate.CreatedAt) {
+		// A failed key keeps its original creation time.
+		reserved.CreatedAt = record.CreatedAt
+	}
+
+	db.idempotencyKeys[candidate.Key] = reserved
+
+	return cloneIdempotencyRecord(reserved), true, nil
+}
+
+func (db *DB) CompleteIdempotencyKey(ctx context.Context, key string, order *booking.Order) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	trxID, ok := transactionIDFromContext(ctx)
+	if !ok || trxID == "" {
+		return ErrTransactionIDNotFoundInCtx
+	}
+
+	trx, exists := db.transactions[trxID]
+	if !exists {
+		return fmt.Errorf("transaction %s not found: %w", trxID, ErrTransactionNotFound)
+	}
+
+	if _, exists := db.idempotencyKeys[key]; !exists {
+		return fmt.Errorf("idempotency key %v: %w", key, booking.ErrRecordNotFound)
+	}
+
+	trx.idempotentResults[key] = cloneOrder(order)
+
+	return nil
+}
+
+func (db *DB) FailIdempotencyKey(_ context.Context, key string) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	record, exists := db.idempotencyKeys[key]
+	if !exists {
+		return fmt.Errorf("idempotency key %v: %w", key, booking.ErrRecordNotFound)
+	}
+
+	if record.Status == booking.IdempotencyInProgress {
+		record.Status = booking.IdempotencyFailed
+		record.UpdatedAt = time.Now().UTC()
+	}
+
+	return nil
+}
+
+func (db *DB) PurgeIdempotencyKeys(_ context.Context, before time.Time) (int, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	var purged int
+
+	for key, record := range db.idempotencyKeys {
+		if record.ExpiresAt.Before(before) {
+			delete(db.idempotencyKeys, key)
+			purged++
+		}
+	}
+
+	return purged, nil
+}
+
+func (db *DB) GetRates(_ context.Context, inputs []booking.GetAvailabilityInput) ([]*booking.RoomRate, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	var result []*booking.RoomRate
+
+	for _, input := range inputs {
+		for d := input.From; d.Before(input.To); d = d.AddDate(0, 0, 1) {
+			if rate, ok := db.rates[availabilityKey(input.HotelID, input.RoomID, d)]; ok {
+				clone := *rate
+				result = append(result, &clone)
+			}
+		}
+	}
+
+	return result, nil
+}
+
+func (db *DB) SaveRates(ctx context.Context, rates []*booking.RoomRate) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	trxID, ok := transactionIDFromContext(ctx)
+	if !ok || trxID == "" {
+		return ErrTransactionIDNotFoundInCtx
+	}
+
+	trx, exists := db.transactions[trxID]
+	if !exists {
+		return fmt.Errorf("transaction %s not found: %w", trxID, ErrTransactionNotFound)
+	}
+
+	for _, rate := range rates {
+		clone := *rate
+		trx.rateModifications[availabilityKey(rate.HotelID, rate.RoomID, rate.Date)] = &clone
+	}
+
+	return nil
+}
+
+func (db *DB) GetRestrictions(_ context.Context, inputs []booking.GetAvailabilityInput) ([]*booking.StayRestriction, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	var result []*booking.StayRestriction
+
+	for _, input := range inputs {
+		for d := input.From; d.Before(input.To); d = d.AddDate(0, 0, 1) {
+			if restriction, ok := db.restrictions[availabilityKey(input.HotelID, input.RoomID, d)]; ok {
+				clone := *restriction
+				result = append(result, &clone)
+			}
+		}
+	}
+
+	return result, nil
+}
+
+func (db *DB) SaveRestrictions(ctx context.Context, restrictions []*booking.StayRestriction) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	trxID, ok := transactionIDFromContext(ctx)
+	if !ok || trxID == "" {
+		return ErrTransactionIDNotFoundInCtx
+	}
+
+	trx, exists := db.transactions[trxID]
+	if !exists {
+		return fmt.Errorf("transaction %s not found: %w", trxID, ErrTransactionNotFound)
+	}
+
+	for _, restriction := range restrictions {
+		clone := *restriction
+		trx.restrictionModifications[availabilityKey(restriction.HotelID, restriction.RoomID, restriction.Date)] = &clone
+	}
+
+	return nil
+}
+
+func clonePromo(promo *boost.Promo) *boost.Promo {
+	clone := *promo
+	clone.HotelIDs = append([]string(nil), promo.HotelIDs...)
+	clone.RoomIDs = append([]string(nil), promo.RoomIDs...)
+
+	return &clone
+}
+
+func (db *DB) GetPromoCodes(_ context.Context, codes []string) ([]*boost.Promo, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	result := make([]*boost.Promo, 0, len(codes))
+
+	for _, code := range codes {
+		if promo, ok := db.promoCodes[code]; ok {
+			result = append(result, clonePromo(promo))
+		}
+	}
+
+	return result, nil
+}
+
+func (db *DB) SavePromoCodes(ctx context.Context, promos []*boost.Promo) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	trxID, ok := transactionIDFromContext(ctx)
+	if !ok || trxID == "" {
+		return ErrTransactionIDNotFoundInCtx
+	}
+
+	trx, exists := db.transactions[trxID]
+	if !exists {
+		return fmt.Errorf("transaction %s not found: %w", trxID, ErrTransactionNotFound)
+	}
+
+	for _, promo := range promos {
+		trx.promoModifications[promo.Code] = clonePromo(promo)
+	}
+
+	return nil
+}
+
+func (db *DB) RedeemPromoCodes(ctx context.Context, redemptions []booking.PromoRedemption) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	trxID, ok := transactionIDFromContext(ctx)
+	if !ok || trxID == "" {
+		return ErrTransactionIDNotFoundInCtx
+	}
+
+	trx, exists := db.transactions[trxID]
+	if !exists {
+		return fmt.Errorf("transaction %s not found: %w", trxID, ErrTransactionNotFound)
+	}
+
+	trx.redemptions = append(trx.redemptions, redemptions...)
+
+	return nil
+}
+
+func (db *DB) ReleasePromoCodes(ctx context.Context, orderID int) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	trxID, ok := transactionIDFromContext(ctx)
+	if !ok || trxID == "" {
+		return ErrTransactionIDNotFoundInCtx
+	}
+
+	trx, exists := db.transactions[trxID]
+	if !exists {
+		return fmt.Errorf("transaction %s not found: %w", trxID, ErrTransactionNotFound)
+	}
+
+	trx.releasedPromoUses = append(trx.releasedPromoUses, orderID)
+
+	return nil
 }
@@ -0,0 +1,378 @@
+package memory_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/avstrong/booking/internal/booking"
+	"github.com/avstrong/booking/internal/idgen/simple"
+	"github.com/avstrong/booking/internal/logger"
+	"github.com/avstrong/booking/internal/money"
+	"github.com/avstrong/booking/internal/storage/memory"
+)
+
+const (
+	quota   = 5
+	nights  = 3
+	workers = 50
+)
+
+func newDB(t *testing.T, from time.Time) (*memory.DB, *logger.Logger) {
+	t.Helper()
+
+	l := logger.New(log.New(io.Discard, "", 0))
+	db := memory.New(memory.Config{L: l})
+
+	ctx, err := db.BeginTransaction(context.Background(), "")
+	if err != nil {
+		t.Fatalf("begin transaction: %v", err)
+	}
+
+	availabilities := make([]*booking.RoomAvailability, 0, nights)
+	rates := make([]*booking.RoomRate, 0, nights)
+
+	for i := 0; i < nights; i++ {
+		availabilities = append(availabilities, &booking.RoomAvailability{
+			HotelID: "reddison",
+			RoomID:  "lux",
+			Date:    from.AddDate(0, 0, i),
+			Quota:   quota,
+		})
+		rates = append(rates, &booking.RoomRate{
+			HotelID: "reddison",
+			RoomID:  "lux",
+			Date:    from.AddDate(0, 0, i),
+			Price:   money.FromMajor(5000, "RUB"),
+		})
+	}
+
+	if err := db.SaveRoomAvailabilities(ctx, availabilities); err != nil {
+		t.Fatalf("save room availabilities: %v", err)
+	}
+
+	if err := db.SaveRates(ctx, rates); err != nil {
+		t.Fatalf("save rates: %v", err)
+	}
+
+	//nolint:exhaustruct
+	hotel := &booking.Hotel{ID: "reddison", Name: "Reddison", TimeZone: "UTC", Active: true}
+	if err := db.SaveHotels(ctx, []*booking.Hotel{hotel}); err != nil {
+		t.Fatalf("save hotels: %v", err)
+	}
+
+	//nolint:exhaustruct
+	room := &booking.RoomType{HotelID: "reddison", ID: "lux", Name: "Lux", MaxOccupancy: 2, Active: true}
+	if err := db.SaveRoomTypes(ctx, []*booking.RoomType{room}); err != nil {
+		t.Fatalf("save room types: %v", err)
+	}
+
+	if err := db.CommitTransaction(ctx); err != nil {
+		t.Fatalf("commit transaction: %v", err)
+	}
+
+	return db, l
+}
+
+func assertQuotas(t *testing.T, db *memory.DB, from time.Time, expected int) {
+	t.Helper()
+
+	availabilities, err := db.GetRoomAvailabilities(context.Background(), []booking.GetAvailabilityInput{{
+		HotelID: "reddison",
+		RoomID:  "lux",
+		From:    from,
+		To:      from.AddDate(0, 0, nights),
+	}})
+	if err != nil {
+		t.Fatalf("get room availabilities: %v", err)
+	}
+
+	for _, availability := range availabilities {
+		if availability.Quota != expected {
+			t.Errorf("quota on %v is %v, expected %v", availability.Date, availability.Quota, expected)
+		}
+	}
+}
+
+func TestDB_AdjustQuotasConcurrently(t *testing.T) {
+	t.Parallel()
+
+	from := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	db, _ := newDB(t, from)
+
+	changes := make([]booking.QuotaChange, 0, nights)
+	for i := 0; i < nights; i++ {
+		//nolint:exhaustruct
+		changes = append(changes, booking.QuotaChange{HotelID: "reddison", RoomID: "lux", Date: from.AddDate(0, 0, i), Delta: -1})
+	}
+
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		committed int
+	)
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			ctx, err := db.BeginTransaction(context.Background(), "")
+			if err != nil {
+				t.Errorf("begin transaction: %v", err)
+
+				return
+			}
+
+			if err := db.AdjustQuotas(ctx, changes); err != nil {
+				t.Errorf("adjust quotas: %v", err)
+
+				return
+			}
+
+			err = db.CommitTransaction(ctx)
+			if err != nil && !errors.Is(err, booking.ErrConflict) {
+				t.Errorf("unexpected commit error: %v", err)
+			}
+
+			if err == nil {
+				mu.Lock()
+				committed++
+				mu.Unlock()
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if committed != quota {
+		t.Errorf("%v transactions committed, expected %v", committed, quota)
+	}
+
+	assertQuotas(t, db, from, 0)
+}
+
+func TestDB_CreateOrdersConcurrently(t *testing.T) {
+	t.Parallel()
+
+	from := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, 30)
+	db, l := newDB(t, from)
+
+	//nolint:exhaustruct
+	manager := booking.New(booking.Config{L: l, CommitAttempts: 3}, db, simple.New(), nil, nil)
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		created int
+	)
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			ctx := booking.NewContextWithIdempotencyKey(context.Background(), fmt.Sprintf("key-%d", i))
+
+			//nolint:exhaustruct
+			_, err := manager.CreateOrder(ctx, &booking.BookInput{
+				Payer: booking.Payer{Email: "guest@mail.ru"},
+				Places: []booking.Place{{
+					HotelID: "reddison",
+					RoomID:  "lux",
+					From:    from,
+					To:      from.AddDate(0, 0, nights),
+				}},
+			})
+			if err != nil && booking.IsAvailabilityError(err) == nil && !errors.Is(err, booking.ErrConflict) {
+				t.Errorf("unexpected error: %v", err)
+			}
+
+			if err == nil {
+				mu.Lock()
+				created++
+				mu.Unlock()
+			}
+		}(i)
+	}
+
+	wg.Wait()
+
+	if created != quota {
+		t.Errorf("%v orders created, expected %v", created, quota)
+	}
+
+	assertQuotas(t, db, from, 0)
+}
+
+func TestDB_RollbackKeepsExistingOrder(t *testing.T) {
+	t.Parallel()
+
+	from := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	db, _ := newDB(t, from)
+
+	//nolint:exhaustruct
+	order := &booking.Order{ID: 1, Status: booking.OrderStatusConfirmed, Version: 1}
+
+	ctx, err := db.BeginTransaction(context.Background(), "")
+	if err != nil {
+		t.Fatalf("begin transaction: %v", err)
+	}
+
+	if err := db.SaveOrder(ctx, order); err != nil {
+		t.Fatalf("save order: %v", err)
+	}
+
+	if err := db.CommitTransaction(ctx); err != nil {
+		t.Fatalf("commit transaction: %v", err)
+	}
+
+	ctx, err = db.BeginTransaction(context.Background(), "")
+	if err != nil {
+		t.Fatalf("begin transaction: %v", err)
+	}
+
+	cancelled := *order
+	cancelled.Status = booking.OrderStatusCancelled
+	cancelled.Version = 2
+
+	if err := db.SaveOrder(ctx, &cancelled); err != nil {
+		t.Fatalf("save order: %v", err)
+	}
+
+	if err := db.RollbackTransaction(ctx); err != nil {
+		t.Fatalf("rollback transaction: %v", err)
+	}
+
+	stored, err := db.GetOrder(context.Background(), order.ID)
+	if err != nil {
+		t.Fatalf("get order after rollback: %v", err)
+	}
+
+	if stored.Status != booking.OrderStatusConfirmed {
+		t.Errorf("order status is %v after rollback, expected %v", stored.Status, booking.OrderStatusConfirmed)
+	}
+}
+
+func TestDB_RollbackKeepsExistingAvailabilities(t *testing.T) {
+	t.Parallel()
+
+	from := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	db, _ := newDB(t, from)
+
+	ctx, err := db.BeginTransaction(context.Background(), "")
+	if err != nil {
+		t.Fatalf("begin transaction: %v", err)
+	}
+
+	availabilities := make([]*booking.RoomAvailability, 0, nights)
+	for i := 0; i < nights; i++ {
+		availabilities = append(availabilities, &booking.RoomAvailability{
+			HotelID: "reddison",
+			RoomID:  "lux",
+			Date:    from.AddDate(0, 0, i),
+			Quota:   0,
+		})
+	}
+
+	if err := db.SaveRoomAvailabilities(ctx, availabilities); err != nil {
+		t.Fatalf("save room availabilities: %v", err)
+	}
+
+	if err := db.RollbackTransaction(ctx); err != nil {
+		t.Fatalf("rollback transaction: %v", err)
+	}
+
+	assertQuotas(t, db, from, quota)
+}
+
+func TestDB_AdjustQuotasCreatesMissingNight(t *testing.T) {
+	t.Parallel()
+
+	from := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	db, _ := newDB(t, from)
+	date := from.AddDate(0, 0, nights)
+
+	// Both transactions see the night missing, and both increments have to survive.
+	contexts := make([]context.Context, 0, 2)
+
+	for i := 0; i < 2; i++ {
+		ctx, err := db.BeginTransaction(context.Background(), "")
+		if err != nil {
+			t.Fatalf("begin transaction: %v", err)
+		}
+
+		//nolint:exhaustruct
+		change := booking.QuotaChange{HotelID: "reddison", RoomID: "lux", Date: date, Delta: 2}
+		if err := db.AdjustQuotas(ctx, []booking.QuotaChange{change}); err != nil {
+			t.Fatalf("adjust quotas: %v", err)
+		}
+
+		contexts = append(contexts, ctx)
+	}
+
+	for _, ctx := range contexts {
+		if err := db.CommitTransaction(ctx); err != nil {
+			t.Fatalf("commit transaction: %v", err)
+		}
+	}
+
+	availabilities, err := db.GetRoomAvailabilities(context.Background(), []booking.GetAvailabilityInput{{
+		HotelID: "reddison",
+		RoomID:  "lux",
+		From:    date,
+		To:      date.AddDate(0, 0, 1),
+	}})
+	if err != nil {
+		t.Fatalf("get room availabilities: %v", err)
+	}
+
+	if len(availabilities) != 1 || availabilities[0].Quota != 4 {
+		t.Errorf("availabilities of the new night are %+v, expected quota 4", availabilities)
+	}
+}
+
+func TestDB_AdjustQuotasFailsWithChangedExpectedQuota(t *testing.T) {
+	t.Parallel()
+
+	from := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	db, _ := newDB(t, from)
+
+	// The quota is set to 2 from the full one, but a room is sold before the set is committed.
+	ctx, err := db.BeginTransaction(context.Background(), "")
+	if err != nil {
+		t.Fatalf("begin transaction: %v", err)
+	}
+
+	expected := quota
+	set := booking.QuotaChange{HotelID: "reddison", RoomID: "lux", Date: from, Delta: 2 - quota, Expected: &expected}
+
+	if err := db.AdjustQuotas(ctx, []booking.QuotaChange{set}); err != nil {
+		t.Fatalf("adjust quotas: %v", err)
+	}
+
+	booked, err := db.BeginTransaction(context.Background(), "")
+	if err != nil {
+		t.Fatalf("begin transaction: %v", err)
+	}
+
+	//nolint:exhaustruct
+	if err := db.AdjustQuotas(booked, []booking.QuotaChange{{HotelID: "reddison", RoomID: "lux", Date: from, Delta: -1}}); err != nil {
+		t.Fatalf("adjust quotas: %v", err)
+	}
+
+	if err := db.CommitTransaction(booked); err != nil {
+		t.Fatalf("commit transaction: %v", err)
+	}
+
+	if err := db.CommitTransaction(ctx); !errors.Is(err, booking.ErrConflict) {
+		t.Errorf("commit of the set quota got %v, expected %v", err, booking.ErrConflict)
+	}
+}
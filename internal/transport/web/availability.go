@@ -0,0 +1,42 @@
+package web
+
+import (
+	"net/http"
+
+	"github.com/avstrong/booking/internal/booking"
+)
+
+// availabilityHandler searches nightly quota and prices. hotel_id and room_id may be repeated.
+func (s *Server) availabilityHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	parser := newQueryParser(query)
+
+	// One guest is searched for when the count is not given.
+	guests := 1
+	if query.Has("guests") {
+		guests = parser.int("guests")
+	}
+
+	search := &booking.AvailabilitySearch{
+		HotelIDs: query["hotel_id"],
+		RoomIDs:  query["room_id"],
+		From:     parser.time("from"),
+		To:       parser.time("to"),
+		Guests:   guests,
+	}
+
+	if len(parser.errors) > 0 {
+		s.writeJSON(w, http.StatusBadRequest, parser.errors)
+
+		return
+	}
+
+	out, err := s.bManager.SearchAvailability(r.Context(), search)
+	if err != nil {
+		s.writeError(w, err, "search availability")
+
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, out)
+}
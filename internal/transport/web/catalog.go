@@ -0,0 +1,150 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/avstrong/booking/internal/booking"
+)
+
+func (s *Server) listHotelsHandler(w http.ResponseWriter, r *http.Request) {
+	out, err := s.bManager.ListHotels(r.Context())
+	if err != nil {
+		s.writeError(w, err, "list hotels")
+
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, out)
+}
+
+func (s *Server) getHotelHandler(w http.ResponseWriter, r *http.Request) {
+	out, err := s.bManager.GetHotel(r.Context(), r.PathValue("hotel_id"))
+	if err != nil {
+		s.writeError(w, err, "get a hotel")
+
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, out)
+}
+
+func (s *Server) createHotelHandler(w http.ResponseWriter, r *http.Request) {
+	var input booking.Hotel
+
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+
+		return
+	}
+
+	out, err := s.bManager.CreateHotel(r.Context(), &input)
+	if err != nil {
+		s.writeError(w, err, "create a hotel")
+
+		return
+	}
+
+	s.writeJSON(w, http.StatusCreated, out)
+}
+
+func (s *Server) updateHotelHandler(w http.ResponseWriter, r *http.Request) {
+	var input booking.Hotel
+
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+
+		return
+	}
+
+	out, err := s.bManager.UpdateHotel(r.Context(), r.PathValue("hotel_id"), &input)
+	if err != nil {
+		s.writeError(w, err, "update a hotel")
+
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, out)
+}
+
+func (s *Server) deactivateHotelHandler(w http.ResponseWriter, r *http.Request) {
+	out, err := s.bManager.DeactivateHotel(r.Context(), r.PathValue("hotel_id"))
+	if err != nil {
+		s.writeError(w, err, "deactivate a hotel")
+
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, out)
+}
+
+func (s *Server) listRoomTypesHandler(w http.ResponseWriter, r *http.Request) {
+	out, err := s.bManager.ListRoomTypes(r.Context(), r.PathValue("hotel_id"))
+	if err != nil {
+		s.writeError(w, err, "list room types")
+
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, out)
+}
+
+func (s *Server) getRoomTypeHandler(w http.ResponseWriter, r *http.Request) {
+	out, err := s.bManager.GetRoomType(r.Context(), r.PathValue("hotel_id"), r.PathValue("room_id"))
+	if err != nil {
+		s.writeError(w, err, "get a room type")
+
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, out)
+}
+
+func (s *Server) createRoomTypeHandler(w http.ResponseWriter, r *http.Request) {
+	var input booking.RoomType
+
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+
+		return
+	}
+
+	out, err := s.bManager.CreateRoomType(r.Context(), r.PathValue("hotel_id"), &input)
+	if err != nil {
+		s.writeError(w, err, "create a room type")
+
+		return
+	}
+
+	s.writeJSON(w, http.StatusCreated, out)
+}
+
+func (s *Server) updateRoomTypeHandler(w http.ResponseWriter, r *http.Request) {
+	var input booking.RoomType
+
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+
+		return
+	}
+
+	out, err := s.bManager.UpdateRoomType(r.Context(), r.PathValue("hotel_id"), r.PathValue("room_id"), &input)
+	if err != nil {
+		s.writeError(w, err, "update a room type")
+
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, out)
+}
+
+func (s *Server) deactivateRoomTypeHandler(w http.ResponseWriter, r *http.Request) {
+	out, err := s.bManager.DeactivateRoomType(r.Context(), r.PathValue("hotel_id"), r.PathValue("room_id"))
+	if err != nil {
+		s.writeError(w, err, "deactivate a room type")
+
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, out)
+}
@@ -0,0 +1,48 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/avstrong/booking/internal/booking"
+)
+
+// changeInventoryHandler sets, increments or decrements quota of a room over a date range.
+func (s *Server) changeInventoryHandler(w http.ResponseWriter, r *http.Request) {
+	var input booking.InventoryInput
+
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+
+		return
+	}
+
+	out, err := s.bManager.ChangeInventory(r.Context(), &input)
+	if err != nil {
+		s.writeError(w, err, "change inventory")
+
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, out)
+}
+
+// setRestrictionsHandler replaces stay restrictions of a room over a date range.
+func (s *Server) setRestrictionsHandler(w http.ResponseWriter, r *http.Request) {
+	var input booking.RestrictionInput
+
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+
+		return
+	}
+
+	out, err := s.bManager.SetRestrictions(r.Context(), &input)
+	if err != nil {
+		s.writeError(w, err, "set restrictions")
+
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, out)
+}
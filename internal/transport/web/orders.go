@@ -0,0 +1,113 @@
+package web
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/avstrong/booking/internal/booking"
+)
+
+// parseTime accepts both RFC 3339 timestamps and plain dates.
+func parseTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.DateOnly, value); err == nil {
+		return t, nil
+	}
+
+	return time.Parse(time.RFC3339, value) //nolint:wrapcheck
+}
+
+type queryParser struct {
+	query  url.Values
+	errors map[string][]string
+}
+
+func newQueryParser(query url.Values) *queryParser {
+	return &queryParser{
+		query:  query,
+		errors: make(map[string][]string),
+	}
+}
+
+func (p *queryParser) time(key string) time.Time {
+	value := p.query.Get(key)
+	if value == "" {
+		return time.Time{}
+	}
+
+	t, err := parseTime(value)
+	if err != nil {
+		p.errors[key] = append(p.errors[key], "provide date (2006-01-02) or RFC 3339 timestamp")
+	}
+
+	return t
+}
+
+func (p *queryParser) int(key string) int {
+	value := p.query.Get(key)
+	if value == "" {
+		return 0
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		p.errors[key] = append(p.errors[key], "provide integer")
+	}
+
+	return n
+}
+
+func (s *Server) getOrderHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := orderIDFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	out, err := s.bManager.GetOrder(r.Context(), id)
+	if err != nil {
+		s.writeError(w, err, "get an order")
+
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, out)
+}
+
+func (s *Server) listOrdersHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	parser := newQueryParser(query)
+
+	statuses := make([]booking.OrderStatus, 0, len(query["status"]))
+	for _, status := range query["status"] {
+		statuses = append(statuses, booking.OrderStatus(status))
+	}
+
+	filter := booking.OrderFilter{
+		PayerEmail:  query.Get("payer_email"),
+		HotelID:     query.Get("hotel_id"),
+		RoomID:      query.Get("room_id"),
+		StayFrom:    parser.time("stay_from"),
+		StayTo:      parser.time("stay_to"),
+		CreatedFrom: parser.time("created_from"),
+		CreatedTo:   parser.time("created_to"),
+		Statuses:    statuses,
+		AfterID:     0,
+		Limit:       parser.int("limit"),
+	}
+
+	if len(parser.errors) > 0 {
+		s.writeJSON(w, http.StatusBadRequest, parser.errors)
+
+		return
+	}
+
+	out, err := s.bManager.ListOrders(r.Context(), filter, query.Get("cursor"))
+	if err != nil {
+		s.writeError(w, err, "list orders")
+
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, out)
+}
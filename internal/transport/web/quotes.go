@@ -0,0 +1,22 @@
+package web
+
+import "net/http"
+
+// quoteHandler prices a booking request without booking it. It needs no Idempotency-Key.
+func (s *Server) quoteHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	input, ok := s.decodeBookInput(w, r)
+	if !ok {
+		return
+	}
+
+	out, err := s.bManager.Quote(ctx, input)
+	if err != nil {
+		s.writeError(w, err, "quote an order")
+
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, out)
+}
@@ -1,15 +1,16 @@
 package web
 
 import (
-	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
 
 	"github.com/avstrong/booking/internal/booking"
 )
 
-func (s *Server) checkRequest(ctx context.Context, w http.ResponseWriter, r *http.Request) (*booking.BookInput, string) {
+func (s *Server) checkRequest(w http.ResponseWriter, r *http.Request) (*booking.BookInput, string) {
 	idempotencyKey := r.Header.Get("Idempotency-Key")
 	if idempotencyKey == "" {
 		http.Error(w, "Idempotency-Key header is missing", http.StatusBadRequest)
@@ -17,33 +18,124 @@ func (s *Server) checkRequest(ctx context.Context, w http.ResponseWriter, r *htt
 		return nil, ""
 	}
 
+	input, ok := s.decodeBookInput(w, r)
+	if !ok {
+		return nil, ""
+	}
+
+	return input, idempotencyKey
+}
+
+// decodeBookInput reads a booking request. Its boost strategies are resolved by the booking manager.
+func (s *Server) decodeBookInput(w http.ResponseWriter, r *http.Request) (*booking.BookInput, bool) {
 	var input booking.BookInput
 
 	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
 		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
 
-		return nil, ""
+		return nil, false
 	}
 
-	if s.boost != nil {
-		strategies, err := s.boost.Strategies(ctx)
-		if err != nil {
-			s.l.LogErrorf("Could not get boost strategies: %v", err.Error())
-			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
-		}
+	return &input, true
+}
+
+func (s *Server) writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		s.l.LogErrorf("Could not encode response: %v", err.Error())
+	}
+}
+
+// writeError maps booking errors to http responses. Unknown errors are logged and reported as 500.
+func (s *Server) writeError(w http.ResponseWriter, err error, action string) {
+	if inputErr := booking.IsInputError(err); inputErr != nil {
+		s.writeJSON(w, http.StatusBadRequest, inputErr.Fields())
+
+		return
+	}
+
+	if availabilityErr := booking.IsAvailabilityError(err); availabilityErr != nil {
+		// A booking which also breaks restrictions gets both lists, named as on a quote.
+		if restrictionErr := booking.IsRestrictionError(err); restrictionErr != nil {
+			s.writeJSON(w, http.StatusPreconditionFailed, map[string]any{
+				"unavailable": availabilityErr.Fields(),
+				"violations":  restrictionErr.Violations,
+			})
 
-		if len(strategies) != 0 {
-			input.BoostStrategies = strategies
+			return
 		}
+
+		s.writeJSON(w, http.StatusPreconditionFailed, availabilityErr.Fields())
+
+		return
+	}
+
+	if transitionErr := booking.IsTransitionError(err); transitionErr != nil {
+		http.Error(w, transitionErr.Error(), http.StatusConflict)
+
+		return
+	}
+
+	if promoLimitErr := booking.IsPromoLimitError(err); promoLimitErr != nil {
+		http.Error(w, promoLimitErr.Error(), http.StatusConflict)
+
+		return
+	}
+
+	if restrictionErr := booking.IsRestrictionError(err); restrictionErr != nil {
+		s.writeJSON(w, http.StatusPreconditionFailed, restrictionErr.Violations)
+
+		return
+	}
+
+	if overbookingErr := booking.IsOverbookingError(err); overbookingErr != nil {
+		http.Error(w, overbookingErr.Error(), http.StatusConflict)
+
+		return
+	}
+
+	switch {
+	case errors.Is(err, booking.ErrRecordNotFound):
+		http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+	case errors.Is(err, booking.ErrAlreadyExists):
+		http.Error(w, booking.ErrAlreadyExists.Error(), http.StatusConflict)
+	case errors.Is(err, booking.ErrOrderNotModifiable):
+		http.Error(w, booking.ErrOrderNotModifiable.Error(), http.StatusConflict)
+	case errors.Is(err, booking.ErrHoldExpired):
+		http.Error(w, booking.ErrHoldExpired.Error(), http.StatusConflict)
+	case errors.Is(err, booking.ErrHoldActive):
+		http.Error(w, booking.ErrHoldActive.Error(), http.StatusConflict)
+	case errors.Is(err, booking.ErrIdempotencyKeyInProgress):
+		http.Error(w, booking.ErrIdempotencyKeyInProgress.Error(), http.StatusConflict)
+	case errors.Is(err, booking.ErrIdempotencyKeyMismatch):
+		http.Error(w, booking.ErrIdempotencyKeyMismatch.Error(), http.StatusUnprocessableEntity)
+	case errors.Is(err, booking.ErrIdempotencyKey):
+		http.Error(w, "Idempotency-Key header is missing", http.StatusBadRequest)
+	case errors.Is(err, booking.ErrConflict):
+		http.Error(w, "rooms were taken by concurrent bookings, try again", http.StatusConflict)
+	default:
+		s.l.LogErrorf("Could not %s: %v", action, err.Error())
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+	}
+}
+
+func orderIDFromPath(w http.ResponseWriter, r *http.Request) (int, bool) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil || id < 1 {
+		http.Error(w, "order id must be a positive integer", http.StatusBadRequest)
+
+		return 0, false
 	}
 
-	return &input, idempotencyKey
+	return id, true
 }
 
 func (s *Server) createOrderHandler(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
-	input, idempotencyKey := s.checkRequest(ctx, w, r)
+	input, idempotencyKey := s.checkRequest(w, r)
 	if idempotencyKey == "" {
 		return
 	}
@@ -51,41 +143,106 @@ func (s *Server) createOrderHandler(w http.ResponseWriter, r *http.Request) {
 	ctx = booking.NewContextWithIdempotencyKey(ctx, idempotencyKey)
 
 	out, err := s.bManager.CreateOrder(ctx, input)
-	if inputErr := booking.IsInputError(err); inputErr != nil {
-		w.WriteHeader(http.StatusBadRequest)
+	if err != nil {
+		s.writeError(w, err, "create an order")
 
-		if err = json.NewEncoder(w).Encode(inputErr.Fields()); err != nil {
-			s.l.LogErrorf("Could not encode validation err: %v", err.Error())
-			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
-		}
+		return
+	}
+
+	s.writeJSON(w, http.StatusCreated, out)
+}
 
+func (s *Server) updateOrderHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := orderIDFromPath(w, r)
+	if !ok {
 		return
 	}
 
-	if availabilityErr := booking.IsAvailabilityError(err); availabilityErr != nil {
-		w.WriteHeader(http.StatusPreconditionFailed)
+	idempotencyKey := r.Header.Get("Idempotency-Key")
+	if idempotencyKey == "" {
+		http.Error(w, "Idempotency-Key header is missing", http.StatusBadRequest)
 
-		if err = json.NewEncoder(w).Encode(availabilityErr.Fields()); err != nil {
-			s.l.LogErrorf("Could not encode availability err: %v", err.Error())
-			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
-		}
+		return
+	}
+
+	var input booking.UpdateOrderInput
+
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
 
 		return
 	}
 
+	ctx := booking.NewContextWithIdempotencyKey(r.Context(), idempotencyKey)
+
+	out, err := s.bManager.UpdateOrder(ctx, id, &input)
 	if err != nil {
-		s.l.LogErrorf("Could not create an order: %v", err.Error())
-		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		s.writeError(w, err, "update an order")
 
 		return
 	}
 
-	w.WriteHeader(http.StatusCreated)
+	s.writeJSON(w, http.StatusOK, out)
+}
 
-	if err = json.NewEncoder(w).Encode(out); err != nil {
-		s.l.LogErrorf("Could not encode result of order creating: %v", err.Error())
-		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+func (s *Server) cancelOrderHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := orderIDFromPath(w, r)
+	if !ok {
+		return
 	}
+
+	out, err := s.bManager.CancelOrder(r.Context(), id)
+	if err != nil {
+		s.writeError(w, err, "cancel an order")
+
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, out)
+}
+
+func (s *Server) confirmOrderHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := orderIDFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	out, err := s.bManager.ConfirmOrder(r.Context(), id)
+	if err != nil {
+		s.writeError(w, err, "confirm an order")
+
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, out)
+}
+
+type changeStatusRequest struct {
+	Status booking.OrderStatus `json:"status"`
+}
+
+func (s *Server) changeOrderStatusHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := orderIDFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	var req changeStatusRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+
+		return
+	}
+
+	out, err := s.bManager.ChangeOrderStatus(r.Context(), id, req.Status)
+	if err != nil {
+		s.writeError(w, err, "change order status")
+
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, out)
 }
 
 func (s *Server) livenessHandler(w http.ResponseWriter, _ *http.Request) {
@@ -97,6 +254,86 @@ func (s *Server) addRoutes(r *http.ServeMux) {
 		"POST /api/orders/v1",
 		s.applyMiddlewares(http.HandlerFunc(s.createOrderHandler), s.loggerMiddleware(), s.recoverMiddleware()),
 	)
+	r.Handle(
+		"POST /api/quotes/v1",
+		s.applyMiddlewares(http.HandlerFunc(s.quoteHandler), s.loggerMiddleware(), s.recoverMiddleware()),
+	)
+	r.Handle(
+		"GET /api/availability/v1",
+		s.applyMiddlewares(http.HandlerFunc(s.availabilityHandler), s.loggerMiddleware(), s.recoverMiddleware()),
+	)
+	r.Handle(
+		"POST /api/admin/inventory/v1",
+		s.applyMiddlewares(http.HandlerFunc(s.changeInventoryHandler), s.loggerMiddleware(), s.recoverMiddleware()),
+	)
+	r.Handle(
+		"POST /api/admin/restrictions/v1",
+		s.applyMiddlewares(http.HandlerFunc(s.setRestrictionsHandler), s.loggerMiddleware(), s.recoverMiddleware()),
+	)
+	r.Handle(
+		"GET /api/orders/v1",
+		s.applyMiddlewares(http.HandlerFunc(s.listOrdersHandler), s.loggerMiddleware(), s.recoverMiddleware()),
+	)
+	r.Handle(
+		"GET /api/orders/v1/{id}",
+		s.applyMiddlewares(http.HandlerFunc(s.getOrderHandler), s.loggerMiddleware(), s.recoverMiddleware()),
+	)
+	r.Handle(
+		"PATCH /api/orders/v1/{id}",
+		s.applyMiddlewares(http.HandlerFunc(s.updateOrderHandler), s.loggerMiddleware(), s.recoverMiddleware()),
+	)
+	r.Handle(
+		"DELETE /api/orders/v1/{id}",
+		s.applyMiddlewares(http.HandlerFunc(s.cancelOrderHandler), s.loggerMiddleware(), s.recoverMiddleware()),
+	)
+	r.Handle(
+		"POST /api/orders/v1/{id}/confirm",
+		s.applyMiddlewares(http.HandlerFunc(s.confirmOrderHandler), s.loggerMiddleware(), s.recoverMiddleware()),
+	)
+	r.Handle(
+		"POST /api/orders/v1/{id}/status",
+		s.applyMiddlewares(http.HandlerFunc(s.changeOrderStatusHandler), s.loggerMiddleware(), s.recoverMiddleware()),
+	)
+	r.Handle(
+		"GET /api/hotels/v1",
+		s.applyMiddlewares(http.HandlerFunc(s.listHotelsHandler), s.loggerMiddleware(), s.recoverMiddleware()),
+	)
+	r.Handle(
+		"POST /api/hotels/v1",
+		s.applyMiddlewares(http.HandlerFunc(s.createHotelHandler), s.loggerMiddleware(), s.recoverMiddleware()),
+	)
+	r.Handle(
+		"GET /api/hotels/v1/{hotel_id}",
+		s.applyMiddlewares(http.HandlerFunc(s.getHotelHandler), s.loggerMiddleware(), s.recoverMiddleware()),
+	)
+	r.Handle(
+		"PUT /api/hotels/v1/{hotel_id}",
+		s.applyMiddlewares(http.HandlerFunc(s.updateHotelHandler), s.loggerMiddleware(), s.recoverMiddleware()),
+	)
+	r.Handle(
+		"DELETE /api/hotels/v1/{hotel_id}",
+		s.applyMiddlewares(http.HandlerFunc(s.deactivateHotelHandler), s.loggerMiddleware(), s.recoverMiddleware()),
+	)
+	r.Handle(
+		"GET /api/hotels/v1/{hotel_id}/rooms",
+		s.applyMiddlewares(http.HandlerFunc(s.listRoomTypesHandler), s.loggerMiddleware(), s.recoverMiddleware()),
+	)
+	r.Handle(
+		"POST /api/hotels/v1/{hotel_id}/rooms",
+		s.applyMiddlewares(http.HandlerFunc(s.createRoomTypeHandler), s.loggerMiddleware(), s.recoverMiddleware()),
+	)
+	r.Handle(
+		"GET /api/hotels/v1/{hotel_id}/rooms/{room_id}",
+		s.applyMiddlewares(http.HandlerFunc(s.getRoomTypeHandler), s.loggerMiddleware(), s.recoverMiddleware()),
+	)
+	r.Handle(
+		"PUT /api/hotels/v1/{hotel_id}/rooms/{room_id}",
+		s.applyMiddlewares(http.HandlerFunc(s.updateRoomTypeHandler), s.loggerMiddleware(), s.recoverMiddleware()),
+	)
+	r.Handle(
+		"DELETE /api/hotels/v1/{hotel_id}/rooms/{room_id}",
+		s.applyMiddlewares(http.HandlerFunc(s.deactivateRoomTypeHandler), s.loggerMiddleware(), s.recoverMiddleware()),
+	)
 	r.Handle(
 		fmt.Sprintf("GET %s", s.conf.LivenessEndpoint),
 		s.applyMiddlewares(http.HandlerFunc(s.livenessHandler), s.loggerMiddleware(), s.recoverMiddleware()),
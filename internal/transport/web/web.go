@@ -8,7 +8,6 @@ import (
 	"time"
 
 	"github.com/avstrong/booking/internal/booking"
-	"github.com/avstrong/booking/internal/boost"
 	"github.com/avstrong/booking/internal/logger"
 )
 
@@ -18,7 +17,6 @@ type Server struct {
 	l        *logger.Logger
 	conf     Conf
 	bManager *booking.Manager
-	boost    *boost.Manager
 }
 
 type Conf struct {
@@ -30,7 +28,7 @@ type Conf struct {
 	LivenessEndpoint  string
 }
 
-func New(ctx context.Context, conf Conf, bookingManager *booking.Manager, boost *boost.Manager) (*Server, error) {
+func New(ctx context.Context, conf Conf, bookingManager *booking.Manager) (*Server, error) {
 	mux := http.NewServeMux()
 
 	//nolint:exhaustruct
@@ -50,7 +48,6 @@ func New(ctx context.Context, conf Conf, bookingManager *booking.Manager, boost
 		l:        conf.L,
 		conf:     conf,
 		bManager: bookingManager,
-		boost:    boost,
 	}
 
 	server.addRoutes(mux)
@@ -3,6 +3,7 @@ package main
 import (
 	"log"
 	"os"
+	_ "time/tzdata" // hotels' time zones must not depend on the host's zoneinfo
 
 	"github.com/avstrong/booking/internal/app"
 	"github.com/avstrong/booking/internal/logger"